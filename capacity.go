@@ -0,0 +1,105 @@
+package redsync
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// capacityRefreshInterval is how long the memory usage read for WithCapacityWarn is cached.
+const capacityRefreshInterval = 10 * time.Second
+
+// A capacityMonitor tracks the memory usage of the pools of a mutex created with WithCapacityWarn.
+type capacityMonitor struct {
+	threshold float64
+	warn      func(usedRatio float64)
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	ratio     float64
+}
+
+var infoMemoryScript = redis.NewScript(0, `
+	return redis.call("INFO", "memory")
+`)
+
+// checkCapacity calls the warn function of the WithCapacityWarn option if the memory usage of the pools exceeds its
+// threshold, refreshing the usage first if it is stale.
+func (m *Mutex) checkCapacity(ctx context.Context) {
+	c := m.capacity
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if time.Since(c.checkedAt) >= capacityRefreshInterval {
+		c.ratio = m.usedMemoryRatio(ctx)
+		c.checkedAt = time.Now()
+	}
+	ratio := c.ratio
+	c.mu.Unlock()
+
+	if ratio > c.threshold && c.warn != nil {
+		c.warn(ratio)
+	}
+}
+
+// usedMemoryRatio returns the highest ratio of used memory to maxmemory among the pools of m. Pools that cannot be
+// queried or have no maxmemory limit are ignored.
+func (m *Mutex) usedMemoryRatio(ctx context.Context) float64 {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(int64(float64(m.getExpiry())*m.timeoutFactor)))
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		highest float64
+	)
+	_, _ = m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer conn.Close()
+		reply, err := conn.Eval(infoMemoryScript)
+		if err != nil {
+			return false, err
+		}
+		ratio, ok := parseMemoryRatio(replyString(reply))
+		if ok {
+			mu.Lock()
+			if ratio > highest {
+				highest = ratio
+			}
+			mu.Unlock()
+		}
+		return ok, nil
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	return highest
+}
+
+// parseMemoryRatio returns used_memory / maxmemory from the memory section of INFO, and false if maxmemory is not
+// set.
+func parseMemoryRatio(info string) (float64, bool) {
+	var used, limit float64
+	for _, line := range strings.Split(info, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "used_memory":
+			used, _ = strconv.ParseFloat(value, 64)
+		case "maxmemory":
+			limit, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+	if limit <= 0 {
+		return 0, false
+	}
+	return used / limit, true
+}
@@ -0,0 +1,250 @@
+// Package dapr provides a mutex that acquires and releases distributed locks through the lock building block of a
+// Dapr sidecar, instead of connecting to Redis directly. The lock store, Redis or otherwise, is configured as a Dapr
+// component.
+package dapr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"time"
+
+	"github.com/dapr/go-sdk/client"
+	"github.com/go-redsync/redsync/v4"
+)
+
+const (
+	minRetryDelayMilliSec = 50
+	maxRetryDelayMilliSec = 250
+)
+
+// Unlock status codes returned by the Dapr sidecar.
+const (
+	unlockSuccess          = 0
+	unlockLockDoesNotExist = 1
+	unlockBelongsToOthers  = 2
+)
+
+// A LockClient is the subset of the Dapr client used by Mutex. It is satisfied by client.Client.
+type LockClient interface {
+	TryLockAlpha1(ctx context.Context, storeName string, request *client.LockRequest) (*client.LockResponse, error)
+	UnlockAlpha1(ctx context.Context, storeName string, request *client.UnlockRequest) (*client.UnlockResponse, error)
+}
+
+// A Mutex is a distributed mutual exclusion lock held in a Dapr lock store. It mirrors the lock and unlock methods of
+// redsync.Mutex, but the lock operations are performed by the Dapr sidecar.
+type Mutex struct {
+	client    LockClient
+	storeName string
+	name      string
+	value     string
+
+	expiry     time.Duration
+	tries      int
+	delayFunc  func(tries int) time.Duration
+	genValueFn func() (string, error)
+}
+
+// An Option configures a mutex.
+type Option interface {
+	Apply(*Mutex)
+}
+
+// OptionFunc is a function that configures a mutex.
+type OptionFunc func(*Mutex)
+
+// Apply calls f(mutex)
+func (f OptionFunc) Apply(mutex *Mutex) {
+	f(mutex)
+}
+
+// WithExpiry can be used to set the expiry of a mutex to the given value. Dapr expresses lock expiries in whole
+// seconds, so expiry is rounded up to the next second. The default is 8s.
+func WithExpiry(expiry time.Duration) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.expiry = expiry
+	})
+}
+
+// WithTries can be used to set the number of times lock acquire is attempted. The default is 32.
+func WithTries(tries int) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.tries = tries
+	})
+}
+
+// WithRetryDelay can be used to set the amount of time to wait between lock acquire attempts.
+func WithRetryDelay(delay time.Duration) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.delayFunc = func(tries int) time.Duration {
+			return delay
+		}
+	})
+}
+
+// WithRetryDelayFunc can be used to override default delay behavior.
+func WithRetryDelayFunc(delayFunc redsync.DelayFunc) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.delayFunc = delayFunc
+	})
+}
+
+// WithGenValueFunc can be used to set the custom value generator, which is used as the lock owner.
+func WithGenValueFunc(genValueFunc func() (string, error)) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.genValueFn = genValueFunc
+	})
+}
+
+// WithValue can be used to assign the lock owner for a mutex. This is necessary if you want to unlock a mutex from a
+// different process.
+func WithValue(v string) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.value = v
+	})
+}
+
+// NewMutex returns a new mutex for the resource name, held in the Dapr lock store storeName.
+func NewMutex(client LockClient, storeName, name string, options ...Option) *Mutex {
+	m := &Mutex{
+		client:    client,
+		storeName: storeName,
+		name:      name,
+		expiry:    8 * time.Second,
+		tries:     32,
+		delayFunc: func(tries int) time.Duration {
+			return time.Duration(mathrand.Intn(maxRetryDelayMilliSec-minRetryDelayMilliSec)+minRetryDelayMilliSec) * time.Millisecond
+		},
+		genValueFn: genValue,
+	}
+	for _, o := range options {
+		o.Apply(m)
+	}
+	return m
+}
+
+// Name returns mutex name (i.e. the Dapr resource ID).
+func (m *Mutex) Name() string {
+	return m.name
+}
+
+// Value returns the current lock owner. This is the value used to release the lock.
+func (m *Mutex) Value() string {
+	return m.value
+}
+
+// TryLock only attempts to lock m once and returns immediately regardless of success or failure without retrying.
+func (m *Mutex) TryLock() error {
+	return m.TryLockContext(context.Background())
+}
+
+// TryLockContext only attempts to lock m once and returns immediately regardless of success or failure without retrying.
+func (m *Mutex) TryLockContext(ctx context.Context) error {
+	return m.lockContext(ctx, 1)
+}
+
+// Lock locks m. In case it returns an error on failure, you may retry to acquire the lock by calling this method again.
+func (m *Mutex) Lock() error {
+	return m.LockContext(context.Background())
+}
+
+// LockContext locks m. In case it returns an error on failure, you may retry to acquire the lock by calling this method again.
+func (m *Mutex) LockContext(ctx context.Context) error {
+	return m.lockContext(ctx, m.tries)
+}
+
+func (m *Mutex) lockContext(ctx context.Context, tries int) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	value := m.value
+	if value == "" {
+		var err error
+		value, err = m.genValueFn()
+		if err != nil {
+			return err
+		}
+	}
+
+	var timer *time.Timer
+	for i := 0; i < tries; i++ {
+		if i != 0 {
+			if timer == nil {
+				timer = time.NewTimer(m.delayFunc(i))
+			} else {
+				timer.Reset(m.delayFunc(i))
+			}
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				// Exit early if the context is done.
+				return redsync.ErrFailed
+			case <-timer.C:
+				// Fall-through when the delay timer completes.
+			}
+		}
+
+		resp, err := m.client.TryLockAlpha1(ctx, m.storeName, &client.LockRequest{
+			ResourceID:      m.name,
+			LockOwner:       value,
+			ExpiryInSeconds: m.expirySeconds(),
+		})
+		if err != nil {
+			return err
+		}
+		if resp.Success {
+			m.value = value
+			return nil
+		}
+	}
+	return redsync.ErrFailed
+}
+
+// Unlock unlocks m and returns the status of unlock.
+func (m *Mutex) Unlock() (bool, error) {
+	return m.UnlockContext(context.Background())
+}
+
+// UnlockContext unlocks m and returns the status of unlock. It returns false if the lock is held by another owner, and
+// redsync.ErrLockAlreadyExpired if the lock no longer exists.
+func (m *Mutex) UnlockContext(ctx context.Context) (bool, error) {
+	resp, err := m.client.UnlockAlpha1(ctx, m.storeName, &client.UnlockRequest{
+		ResourceID: m.name,
+		LockOwner:  m.value,
+	})
+	if err != nil {
+		return false, err
+	}
+	switch resp.StatusCode {
+	case unlockSuccess:
+		return true, nil
+	case unlockLockDoesNotExist:
+		return false, redsync.ErrLockAlreadyExpired
+	case unlockBelongsToOthers:
+		return false, nil
+	}
+	return false, fmt.Errorf("dapr: unlock failed with status %d: %s", resp.StatusCode, resp.Status)
+}
+
+// expirySeconds returns the expiry of m in whole seconds, rounded up.
+func (m *Mutex) expirySeconds() int32 {
+	s := math.Ceil(m.expiry.Seconds())
+	if s > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int32(s)
+}
+
+func genValue() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
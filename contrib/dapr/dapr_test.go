@@ -0,0 +1,108 @@
+package dapr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dapr/go-sdk/client"
+	"github.com/go-redsync/redsync/v4"
+)
+
+// fakeLockStore emulates the lock building block of a Dapr sidecar.
+type fakeLockStore struct {
+	mu    sync.Mutex
+	locks map[string]string
+	reqs  []*client.LockRequest
+}
+
+func newFakeLockStore() *fakeLockStore {
+	return &fakeLockStore{locks: map[string]string{}}
+}
+
+func (s *fakeLockStore) TryLockAlpha1(ctx context.Context, storeName string, request *client.LockRequest) (*client.LockResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reqs = append(s.reqs, request)
+	key := storeName + "||" + request.ResourceID
+	if _, ok := s.locks[key]; ok {
+		return &client.LockResponse{Success: false}, nil
+	}
+	s.locks[key] = request.LockOwner
+	return &client.LockResponse{Success: true}, nil
+}
+
+func (s *fakeLockStore) UnlockAlpha1(ctx context.Context, storeName string, request *client.UnlockRequest) (*client.UnlockResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := storeName + "||" + request.ResourceID
+	owner, ok := s.locks[key]
+	switch {
+	case !ok:
+		return &client.UnlockResponse{StatusCode: unlockLockDoesNotExist}, nil
+	case owner != request.LockOwner:
+		return &client.UnlockResponse{StatusCode: unlockBelongsToOthers}, nil
+	}
+	delete(s.locks, key)
+	return &client.UnlockResponse{StatusCode: unlockSuccess}, nil
+}
+
+func TestMutex(t *testing.T) {
+	store := newFakeLockStore()
+	mutex := NewMutex(store, "lockstore", "test-dapr", WithExpiry(1500*time.Millisecond))
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if mutex.Value() == "" {
+		t.Fatalf("Expected the lock owner to be set")
+	}
+	if got := store.reqs[0].ExpiryInSeconds; got != 2 {
+		t.Fatalf("Expected an expiry of 2s, got %ds", got)
+	}
+
+	other := NewMutex(store, "lockstore", "test-dapr", WithTries(2), WithRetryDelay(time.Millisecond))
+	err = other.Lock()
+	if err != redsync.ErrFailed {
+		t.Fatalf("Expected err == %q, got %q", redsync.ErrFailed, err)
+	}
+	if len(store.reqs) != 3 {
+		t.Fatalf("Expected 3 lock requests, got %d", len(store.reqs))
+	}
+	ok, err := other.Unlock()
+	if err != nil || ok {
+		t.Fatalf("Expected unlock by another owner to be refused, got %v, %v", ok, err)
+	}
+
+	ok, err = mutex.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+	_, err = mutex.Unlock()
+	if err != redsync.ErrLockAlreadyExpired {
+		t.Fatalf("Expected err == %q, got %q", redsync.ErrLockAlreadyExpired, err)
+	}
+
+	err = other.TryLock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+}
+
+func TestMutexLockContextCanceled(t *testing.T) {
+	store := newFakeLockStore()
+	mutex := NewMutex(store, "lockstore", "test-dapr-canceled")
+	if err := mutex.Lock(); err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	other := NewMutex(store, "lockstore", "test-dapr-canceled", WithRetryDelay(time.Second))
+	err := other.LockContext(ctx)
+	if !errors.Is(err, redsync.ErrFailed) {
+		t.Fatalf("Expected err == %q, got %q", redsync.ErrFailed, err)
+	}
+}
@@ -0,0 +1,32 @@
+module github.com/go-redsync/redsync/v4/contrib/dapr
+
+go 1.22
+
+require (
+	github.com/dapr/go-sdk v1.10.0
+	github.com/go-redsync/redsync/v4 v4.19.0
+)
+
+require (
+	github.com/dapr/dapr v1.13.0-rc.7 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/hashicorp/errwrap v1.1.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	go.opentelemetry.io/otel v1.23.1 // indirect
+	go.opentelemetry.io/otel/trace v1.23.1 // indirect
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	golang.org/x/time v0.5.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240205150955-31a09d347014 // indirect
+	google.golang.org/grpc v1.62.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// v4.19.0 is the first release of redsync with the APIs this module uses. The replace directive builds it against
+// the redsync sources of this repository until then.
+replace github.com/go-redsync/redsync/v4 => ../..
@@ -0,0 +1,22 @@
+module github.com/go-redsync/redsync/v4/contrib/openfeature
+
+go 1.22
+
+require (
+	github.com/go-redsync/redsync/v4 v4.19.0
+	github.com/open-feature/go-sdk v1.14.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/hashicorp/errwrap v1.1.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/time v0.5.0 // indirect
+)
+
+// v4.19.0 is the first release of redsync with the APIs this module uses. The replace directive builds it against
+// the redsync sources of this repository until then.
+replace github.com/go-redsync/redsync/v4 => ../..
@@ -0,0 +1,23 @@
+// Package openfeature enables and disables distributed locking with an OpenFeature feature flag.
+package openfeature
+
+import (
+	"context"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+// WithOpenFeatureFlag can be used to enable or disable distributed locking with the boolean feature flag flagKey,
+// evaluated with client on each lock. fallback is the value used if the flag cannot be evaluated. While the flag is
+// false, the mutex uses the in-process fallback lock if it is created with redsync.WithLocalFallback, and a no-op
+// lock otherwise. See redsync.WithLockToggle.
+func WithOpenFeatureFlag(client openfeature.IClient, flagKey string, fallback bool) redsync.Option {
+	return redsync.WithLockToggle(func(ctx context.Context) bool {
+		enabled, err := client.BooleanValue(ctx, flagKey, fallback, openfeature.EvaluationContext{})
+		if err != nil {
+			return fallback
+		}
+		return enabled
+	})
+}
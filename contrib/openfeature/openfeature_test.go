@@ -0,0 +1,65 @@
+package openfeature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis/memory"
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/open-feature/go-sdk/openfeature/memprovider"
+)
+
+func TestWithOpenFeatureFlag(t *testing.T) {
+	provider := memprovider.NewInMemoryProvider(map[string]memprovider.InMemoryFlag{
+		"distributed-locking": {
+			Key:            "distributed-locking",
+			State:          memprovider.Enabled,
+			DefaultVariant: "off",
+			Variants:       map[string]interface{}{"on": true, "off": false},
+		},
+	})
+	err := openfeature.SetNamedProviderAndWait("redsync-test", provider)
+	if err != nil {
+		t.Fatalf("set provider failed: %s", err)
+	}
+	client := openfeature.NewClient("redsync-test")
+
+	pool := memory.NewPool()
+	rs := redsync.New(pool)
+	mutex := rs.NewMutex("test-openfeature", redsync.WithTries(1), redsync.WithLocalFallback(true),
+		WithOpenFeatureFlag(client, "distributed-locking", false))
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	conn, _ := pool.Get(context.Background())
+	if value, _ := conn.Get("test-openfeature"); value != "" {
+		t.Fatalf("Expected the lock not to be taken in Redis, got %q", value)
+	}
+
+	other := rs.NewMutex("test-openfeature", redsync.WithTries(1), redsync.WithLocalFallback(true),
+		WithOpenFeatureFlag(client, "distributed-locking", false))
+	if err := other.Lock(); err == nil {
+		t.Fatalf("Expected the local fallback lock to be held")
+	}
+
+	ok, err := mutex.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+	err = other.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	_, _ = other.Unlock()
+
+	missing := rs.NewMutex("test-openfeature", WithOpenFeatureFlag(client, "missing-flag", true))
+	err = missing.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if value, _ := conn.Get("test-openfeature"); value != missing.Value() {
+		t.Fatalf("Expected the lock to be taken in Redis when the flag falls back to true, got %q", value)
+	}
+}
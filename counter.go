@@ -0,0 +1,193 @@
+package redsync
+
+import (
+	"context"
+
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/hashicorp/go-multierror"
+)
+
+// A DistributedCounter is a counter stored on the pools of a Redsync instance. Increments are serialized across the
+// cluster with a Mutex, so that each call to Increment increments the count exactly once. It is safe for concurrent
+// use.
+type DistributedCounter struct {
+	name    string
+	rs      *Redsync
+	options []Option
+	pools   []redis.Pool
+	quorum  int
+}
+
+// NewCounter returns a new distributed counter stored in the key name. Increments are serialized with a mutex named
+// "<name>:mutex", created with the given options.
+func (r *Redsync) NewCounter(name string, options ...Option) *DistributedCounter {
+	return &DistributedCounter{
+		name:    name,
+		rs:      r,
+		options: options,
+		pools:   r.pools,
+		quorum:  len(r.pools)/2 + 1,
+	}
+}
+
+var counterGetScript = redis.NewScript(1, `
+	return tonumber(redis.call("GET", KEYS[1]) or "0")
+`)
+
+// counterSetScript sets KEYS[1] to ARGV[1] and returns its previous value.
+var counterSetScript = redis.NewScript(1, `
+	local previous = tonumber(redis.call("GET", KEYS[1]) or "0")
+	redis.call("SET", KEYS[1], ARGV[1])
+	return previous
+`)
+
+// counterResetScript sets KEYS[1] to zero and returns its previous value, unless that value is greater than ARGV[1],
+// in which case it returns -1 and leaves KEYS[1] unchanged.
+var counterResetScript = redis.NewScript(1, `
+	local previous = tonumber(redis.call("GET", KEYS[1]) or "0")
+	if previous > tonumber(ARGV[1]) then
+		return -1
+	end
+	redis.call("SET", KEYS[1], 0)
+	return previous
+`)
+
+// Increment increments the counter and returns its new value. If fewer than a quorum of pools could be incremented,
+// the pools that were are decremented back.
+func (c *DistributedCounter) Increment(ctx context.Context) (int64, error) {
+	mutex := c.newMutex()
+	if err := mutex.LockContext(ctx); err != nil {
+		return 0, err
+	}
+	defer mutex.UnlockContext(ctx)
+
+	values, err := c.eval(ctx, incrScript)
+	if len(values) < c.quorum {
+		for node, value := range values {
+			values[node] = value - 1
+		}
+		c.rollback(ctx, values)
+		return 0, err
+	}
+	return maxValue(values), nil
+}
+
+// Read returns the current value of the counter, without locking.
+func (c *DistributedCounter) Read(ctx context.Context) (int64, error) {
+	return c.evalMax(ctx, counterGetScript)
+}
+
+// Reset sets the counter to zero if its current value, as returned by Read, is expected, and returns whether it was
+// reset. The counter is reset on all pools, including those that missed increments. Each pool is reset with a
+// compare-and-set script that refuses to reset a pool whose value is ahead of expected, as when an increment raced
+// the reset. If a pool refused, or fewer than a quorum of pools could be reset, the pools that were are set back to
+// their previous values.
+func (c *DistributedCounter) Reset(ctx context.Context, expected int64) (bool, error) {
+	mutex := c.newMutex()
+	if err := mutex.LockContext(ctx); err != nil {
+		return false, err
+	}
+	defer mutex.UnlockContext(ctx)
+
+	current, err := c.evalMax(ctx, counterGetScript)
+	if err != nil {
+		return false, err
+	}
+	if current != expected {
+		return false, nil
+	}
+
+	previous, err := c.eval(ctx, counterResetScript, expected)
+	reset := make(map[int]int64, len(previous))
+	for node, value := range previous {
+		if value >= 0 {
+			reset[node] = value
+		}
+	}
+	if len(reset) < len(previous) {
+		c.rollback(ctx, reset)
+		return false, nil
+	}
+	if len(reset) < c.quorum {
+		c.rollback(ctx, reset)
+		return false, err
+	}
+	return true, nil
+}
+
+// rollback sets the counter back to the given values, by node, after an update that failed to reach a quorum of
+// pools. It runs even if ctx is done.
+func (c *DistributedCounter) rollback(ctx context.Context, values map[int]int64) {
+	ctx = context.WithoutCancel(ctx)
+	for node, value := range values {
+		_, _ = c.evalOn(ctx, node, counterSetScript, value)
+	}
+}
+
+// newMutex returns a mutex serializing updates of the counter. A Mutex is not safe for concurrent use, so each
+// operation uses its own.
+func (c *DistributedCounter) newMutex() *Mutex {
+	return c.rs.NewMutex(c.name+":mutex", c.options...)
+}
+
+// evalMax runs script on all pools and returns the greatest reply, provided a quorum of pools replied.
+func (c *DistributedCounter) evalMax(ctx context.Context, script *redis.Script) (int64, error) {
+	values, err := c.eval(ctx, script)
+	if len(values) < c.quorum {
+		return 0, err
+	}
+	return maxValue(values), nil
+}
+
+// maxValue returns the greatest of values, or zero if it is empty.
+func maxValue(values map[int]int64) int64 {
+	var max int64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// eval runs script with the counter's key and args on all pools concurrently, and returns the integer replies of the
+// pools that replied, by node, along with the errors of the others.
+func (c *DistributedCounter) eval(ctx context.Context, script *redis.Script, args ...interface{}) (map[int]int64, error) {
+	type result struct {
+		node  int
+		value int64
+		err   error
+	}
+
+	ch := make(chan result, len(c.pools))
+	for node, pool := range c.pools {
+		go func(node int, pool redis.Pool) {
+			r := result{node: node}
+			r.value, r.err = c.evalOn(ctx, node, script, args...)
+			ch <- r
+		}(node, pool)
+	}
+
+	values := make(map[int]int64, len(c.pools))
+	var err error
+	for range c.pools {
+		r := <-ch
+		if r.err != nil {
+			err = multierror.Append(err, &RedisError{Node: r.node, Err: r.err})
+			continue
+		}
+		values[r.node] = r.value
+	}
+	return values, err
+}
+
+// evalOn runs script with the counter's key and args on the pool of the given node, and returns its integer reply.
+func (c *DistributedCounter) evalOn(ctx context.Context, node int, script *redis.Script, args ...interface{}) (int64, error) {
+	conn, err := c.pools[node].Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	reply, err := conn.Eval(script, append([]interface{}{c.name}, args...)...)
+	return replyInt64(reply), err
+}
@@ -0,0 +1,133 @@
+package redsync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+	"golang.org/x/sync/singleflight"
+)
+
+// dedupPool wraps a pool so that simultaneous identical script evaluations are merged into a single Redis command
+// whose result is returned to all callers.
+type dedupPool struct {
+	delegate redis.Pool
+	group    singleflight.Group
+}
+
+func newDedupPool(delegate redis.Pool) *dedupPool {
+	return &dedupPool{delegate: delegate}
+}
+
+func (p *dedupPool) Get(ctx context.Context) (redis.Conn, error) {
+	return &dedupConn{pool: p, ctx: ctx}, nil
+}
+
+type dedupConn struct {
+	pool     *dedupPool
+	ctx      context.Context
+	delegate redis.Conn
+}
+
+// conn returns the delegate connection used for commands that are not merged, getting it from the pool on first use.
+func (c *dedupConn) conn() (redis.Conn, error) {
+	if c.delegate == nil {
+		conn, err := c.pool.delegate.Get(c.ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.delegate = conn
+	}
+	return c.delegate, nil
+}
+
+func (c *dedupConn) Get(name string) (string, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return "", err
+	}
+	return conn.Get(name)
+}
+
+func (c *dedupConn) Set(name string, value string) (bool, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return false, err
+	}
+	return conn.Set(name, value)
+}
+
+func (c *dedupConn) SetNX(name string, value string, expiry time.Duration) (bool, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return false, err
+	}
+	return conn.SetNX(name, value, expiry)
+}
+
+func (c *dedupConn) PTTL(name string) (time.Duration, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return 0, err
+	}
+	return conn.PTTL(name)
+}
+
+// dedupScripts are the scripts whose evaluations can be merged: they are read-only, or idempotent so that evaluating
+// them once has the same effect as evaluating them for each caller. Scripts such as incrScript, whose effect depends on
+// the number of evaluations, must never be merged.
+var dedupScripts = map[*redis.Script]bool{
+	deleteScript:         true,
+	touchScript:          true,
+	touchWithSetNXScript: true,
+	inspectScript:        true,
+	encodingScript:       true,
+	counterGetScript:     true,
+}
+
+// dedupEvalTimeout bounds a merged evaluation issued by a caller whose context has no deadline.
+const dedupEvalTimeout = 10 * time.Second
+
+// Eval merges the evaluation with any in-flight evaluation of the same script with the same keys and arguments, if
+// the script is one of dedupScripts. The merged command is not cancelled when the context of the caller that issued
+// it first is done, as other callers wait for its result, but it still times out at the deadline of that context, or
+// after dedupEvalTimeout if it has none.
+func (c *dedupConn) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	if !dedupScripts[script] {
+		conn, err := c.conn()
+		if err != nil {
+			return nil, err
+		}
+		return conn.Eval(script, keysAndArgs...)
+	}
+	key := script.Hash + fmt.Sprintf("%#v", keysAndArgs)
+	v, err, _ := c.pool.group.Do(key, func() (interface{}, error) {
+		ctx, cancel := c.evalContext()
+		defer cancel()
+		conn, err := c.pool.delegate.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return conn.Eval(script, keysAndArgs...)
+	})
+	return v, err
+}
+
+// evalContext returns the context of a merged evaluation: it is detached from the cancellation of c.ctx but keeps
+// its deadline.
+func (c *dedupConn) evalContext() (context.Context, context.CancelFunc) {
+	timeout := dedupEvalTimeout
+	if deadline, ok := c.ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	return context.WithTimeout(context.WithoutCancel(c.ctx), timeout)
+}
+
+func (c *dedupConn) Close() error {
+	if c.delegate == nil {
+		return nil
+	}
+	return c.delegate.Close()
+}
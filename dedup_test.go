@@ -0,0 +1,198 @@
+package redsync
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/go-redsync/redsync/v4/redis/memory"
+)
+
+type countingPool struct {
+	redis.Pool
+	evals   int32
+	release chan struct{}
+}
+
+func (p *countingPool) Get(ctx context.Context) (redis.Conn, error) {
+	conn, err := p.Pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{conn, p}, nil
+}
+
+type countingConn struct {
+	redis.Conn
+	pool *countingPool
+}
+
+func (c *countingConn) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	atomic.AddInt32(&c.pool.evals, 1)
+	<-c.pool.release
+	return c.Conn.Eval(script, keysAndArgs...)
+}
+
+func TestDedupPool(t *testing.T) {
+	inner := &countingPool{Pool: memory.NewPool(), release: make(chan struct{})}
+	pool := newDedupPool(inner)
+
+	const n = 8
+	var wg sync.WaitGroup
+	reads := make([]interface{}, n)
+	incrs := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			conn, _ := pool.Get(context.Background())
+			defer conn.Close()
+			reads[i], _ = conn.Eval(counterGetScript, "test-dedup-pool-read")
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			conn, _ := pool.Get(context.Background())
+			defer conn.Close()
+			incrs[i], _ = conn.Eval(incrScript, "test-dedup-pool-incr")
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	// The reads are merged, the increments are not.
+	if evals := atomic.LoadInt32(&inner.evals); evals != 1+n {
+		t.Fatalf("Expected %d evals, got %d", 1+n, evals)
+	}
+	for i, reply := range reads {
+		if reply != int64(0) {
+			t.Fatalf("Expected read %d to be 0, got %v", i, reply)
+		}
+	}
+	seen := map[interface{}]bool{}
+	for _, reply := range incrs {
+		seen[reply] = true
+	}
+	if len(seen) != n {
+		t.Fatalf("Expected %d distinct increments, got %v", n, incrs)
+	}
+}
+
+func TestMutexCommandDedupVersionedLock(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingPool{Pool: memory.NewPool(), release: make(chan struct{})}
+	rs := New(inner)
+
+	const n = 4
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mutex := rs.NewMutex("test-command-dedup-versioned", WithTries(1), WithCommandDedup(true), WithVersionedLock(true))
+			_ = mutex.Lock()
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	conn, err := inner.Get(ctx)
+	if err != nil {
+		t.Fatalf("pool get failed: %s", err)
+	}
+	defer conn.Close()
+	version, err := conn.Get("test-command-dedup-versioned:version")
+	if err != nil {
+		t.Fatalf("version get failed: %s", err)
+	}
+	if version != strconv.Itoa(n) {
+		t.Fatalf("Expected each lock attempt to take its own version, got version %s after %d attempts", version, n)
+	}
+}
+
+func TestMutexCommandDedup(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-command-dedup", WithCommandDedup(true))
+
+			err := mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			assertAcquired(ctx, t, v.pools, mutex)
+
+			ok, err := mutex.Extend()
+			if err != nil || !ok {
+				t.Fatalf("mutex extend failed: %v", err)
+			}
+			ok, err = mutex.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+		})
+	}
+}
+
+type ctxPool struct {
+	redis.Pool
+	err      error
+	deadline time.Time
+	ok       bool
+}
+
+func (p *ctxPool) Get(ctx context.Context) (redis.Conn, error) {
+	conn, err := p.Pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ctxConn{conn, p, ctx}, nil
+}
+
+type ctxConn struct {
+	redis.Conn
+	pool *ctxPool
+	ctx  context.Context
+}
+
+func (c *ctxConn) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	c.pool.err = c.ctx.Err()
+	c.pool.deadline, c.pool.ok = c.ctx.Deadline()
+	return c.Conn.Eval(script, keysAndArgs...)
+}
+
+func TestDedupPoolDeadline(t *testing.T) {
+	inner := &ctxPool{Pool: memory.NewPool()}
+	pool := newDedupPool(inner)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	deadline, _ := ctx.Deadline()
+	cancel()
+	conn, _ := pool.Get(ctx)
+	_, err := conn.Eval(counterGetScript, "test-dedup-pool-deadline")
+	if err != nil {
+		t.Fatalf("eval failed: %s", err)
+	}
+	// The merged evaluation survives the cancellation of the caller, but not its deadline.
+	if inner.err != nil {
+		t.Fatalf("Expected the merged evaluation not to be cancelled, got %s", inner.err)
+	}
+	if !inner.ok || inner.deadline.Sub(deadline).Abs() > time.Second {
+		t.Fatalf("Expected deadline %v, got %v", deadline, inner.deadline)
+	}
+
+	conn, _ = pool.Get(context.Background())
+	_, err = conn.Eval(counterGetScript, "test-dedup-pool-deadline")
+	if err != nil {
+		t.Fatalf("eval failed: %s", err)
+	}
+	if !inner.ok {
+		t.Fatalf("Expected the merged evaluation to have a deadline")
+	}
+}
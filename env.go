@@ -0,0 +1,74 @@
+package redsync
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultRedsync is the Redsync instance used by NewMutexFromEnv. It must be set, typically at startup, before
+// NewMutexFromEnv is called.
+var DefaultRedsync *Redsync
+
+// Environment variables read by NewMutexFromEnv.
+const (
+	envExpiry      = "REDSYNC_EXPIRY"
+	envTries       = "REDSYNC_TRIES"
+	envRetryDelay  = "REDSYNC_RETRY_DELAY"
+	envFailFast    = "REDSYNC_FAIL_FAST"
+	envDriftFactor = "REDSYNC_DRIFT_FACTOR"
+)
+
+// NewMutexFromEnv returns a new distributed mutex with given name, created with DefaultRedsync and configured from
+// the environment:
+//
+//   - REDSYNC_EXPIRY, a duration such as "10s", as with WithExpiry
+//   - REDSYNC_TRIES, a positive integer, as with WithTries
+//   - REDSYNC_RETRY_DELAY, a duration such as "100ms", as with WithRetryDelay
+//   - REDSYNC_FAIL_FAST, a boolean such as "true", as with WithFailFast
+//   - REDSYNC_DRIFT_FACTOR, a number in [0, 1), as with WithDriftFactor
+//
+// Variables that are unset or cannot be parsed are ignored, and the default is used. It returns ErrNoDefaultRedsync if
+// DefaultRedsync is nil.
+func NewMutexFromEnv(name string) (*Mutex, error) {
+	if DefaultRedsync == nil {
+		return nil, ErrNoDefaultRedsync
+	}
+
+	var options []Option
+	if d, ok := envDuration(envExpiry); ok {
+		options = append(options, WithExpiry(d))
+	}
+	if v, ok := os.LookupEnv(envTries); ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			options = append(options, WithTries(n))
+		}
+	}
+	if d, ok := envDuration(envRetryDelay); ok {
+		options = append(options, WithRetryDelay(d))
+	}
+	if v, ok := os.LookupEnv(envFailFast); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			options = append(options, WithFailFast(b))
+		}
+	}
+	if v, ok := os.LookupEnv(envDriftFactor); ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f < 1 {
+			options = append(options, WithDriftFactor(f))
+		}
+	}
+	return DefaultRedsync.NewMutex(name, options...), nil
+}
+
+// envDuration returns the positive duration held by the environment variable key, if any.
+func envDuration(key string) (time.Duration, bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
@@ -3,6 +3,8 @@ package redsync
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // ErrFailed is the error resulting if Redsync fails to acquire the lock after
@@ -16,6 +18,35 @@ var ErrExtendFailed = errors.New("redsync: failed to extend lock")
 // ErrLockAlreadyExpired is the error resulting if trying to unlock the lock which already expired.
 var ErrLockAlreadyExpired = errors.New("redsync: failed to unlock, lock was already expired")
 
+// ErrLockNotHeld is the error resulting if an operation requires the lock to be held by the mutex and it is not.
+var ErrLockNotHeld = errors.New("redsync: lock is not held")
+
+// ErrLockLost is the error resulting if a lock held by a mutex is found to be no longer held, for instance because it
+// expired.
+var ErrLockLost = errors.New("redsync: lock was lost")
+
+// ErrLockLostDuringScope is the error resulting if the function run by Mutex.ScopedLock was cancelled because the lock
+// was lost.
+var ErrLockLostDuringScope = errors.New("redsync: lock was lost during scope")
+
+// ErrNoShards is the error resulting if NewSharded is called without any shard.
+var ErrNoShards = errors.New("redsync: no shards")
+
+// ErrSubscribeUnsupported is the error resulting if Redsync.Subscribe is used and none of the pools implements
+// redis.Subscriber.
+var ErrSubscribeUnsupported = errors.New("redsync: no pool supports Pub/Sub")
+
+// ErrKeyExists is the error resulting if Mutex.MigrateKey is used with the WithSafeRename option and the destination
+// key already exists.
+var ErrKeyExists = errors.New("redsync: destination key already exists")
+
+// ErrScriptingUnsupported is the error reported by Redsync.Validate for a Redis server that does not support
+// scripting, which is required for locking.
+var ErrScriptingUnsupported = errors.New("redsync: Redis server does not support scripting, 2.6 or later is required")
+
+// ErrNoDefaultRedsync is the error resulting if NewMutexFromEnv is called before DefaultRedsync is set.
+var ErrNoDefaultRedsync = errors.New("redsync: DefaultRedsync is not set")
+
 // ErrTaken happens when the lock is already taken in a quorum on nodes.
 type ErrTaken struct {
 	Nodes []int
@@ -48,3 +79,51 @@ func (e RedisError) Error() string {
 func (e RedisError) Unwrap() error {
 	return e.Err
 }
+
+// ErrInvalidTransition is the error resulting if a mutex operation is not allowed in the mutex's current state. It
+// is only returned when the WithStateMachine option is used.
+type ErrInvalidTransition struct {
+	From MutexState
+	To   MutexState
+}
+
+func (err ErrInvalidTransition) Error() string {
+	return fmt.Sprintf("redsync: invalid state transition from %s to %s", err.From, err.To)
+}
+
+// ErrInsufficientTTL is the error resulting if LockWithExpectedTTL could not acquire the lock with a long enough
+// validity. TTL is the longest validity achieved.
+type ErrInsufficientTTL struct {
+	Expected time.Duration
+	TTL      time.Duration
+}
+
+func (err ErrInsufficientTTL) Error() string {
+	return fmt.Sprintf("redsync: lock validity %s is less than expected %s", err.TTL, err.Expected)
+}
+
+// ErrPartialLock is the error resulting if TryLockAll could not lock all the requested names. Available lists the
+// names that could be locked, and were released, and Unavailable those that could not.
+type ErrPartialLock struct {
+	Available   []string
+	Unavailable []string
+}
+
+func (err ErrPartialLock) Error() string {
+	return fmt.Sprintf("redsync: failed to lock all names, unavailable: %v", err.Unavailable)
+}
+
+// ValidationErrors lists the failures found by Redsync.Validate. Failures of a single pool are *RedisError values.
+type ValidationErrors []error
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("redsync: validation failed: %s", strings.Join(msgs, "; "))
+}
+
+func (errs ValidationErrors) Unwrap() []error {
+	return errs
+}
@@ -0,0 +1,154 @@
+package redsync
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// eventsChannelPrefix is the prefix of the Pub/Sub channels on which mutexes created with WithPublishEvents publish
+// their events. It is followed by the name of the mutex.
+const eventsChannelPrefix = "redsync:events:"
+
+// seenEventsSize is the number of recent event IDs Subscribe remembers to drop the copies received from other pools.
+const seenEventsSize = 1024
+
+// A MutexEvent describes a lock operation published by a mutex created with WithPublishEvents. Type is one of
+// EventLock, EventExtend and EventUnlock.
+type MutexEvent struct {
+	ID    string        `json:"id"`
+	Type  LockEventType `json:"type"`
+	Name  string        `json:"name"`
+	Value string        `json:"value"`
+	Owner string        `json:"owner"`
+	Time  time.Time     `json:"time"`
+}
+
+var publishScript = redis.NewScript(0, `
+	return redis.call("PUBLISH", ARGV[1], ARGV[2])
+`)
+
+// publishEvent publishes an event of type typ for the lock held by m on all its pools, if the WithPublishEvents option
+// is used. Publishing is best effort and never causes a mutex operation to fail.
+func (m *Mutex) publishEvent(ctx context.Context, typ LockEventType) {
+	if !m.publishEvents {
+		return
+	}
+	id, err := genValue()
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(MutexEvent{
+		ID:    id,
+		Type:  typ,
+		Name:  m.name,
+		Value: m.Value(),
+		Owner: processOwner(),
+		Time:  time.Now(),
+	})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(int64(float64(m.getExpiry())*m.timeoutFactor)))
+	defer cancel()
+	_, _ = m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer conn.Close()
+		_, err = conn.Eval(publishScript, eventsChannelPrefix+m.name, string(b))
+		return err == nil, err
+	})
+}
+
+// Subscribe returns the events published by mutexes created with WithPublishEvents, on any Redsync instance sharing
+// r's pools, whose name matches the glob-style pattern. Events are received from all the pools that implement
+// redis.Subscriber, and each event is delivered once. The channel is closed once ctx is done or all subscriptions
+// have failed.
+//
+// Subscribe returns ErrSubscribeUnsupported if no pool implements redis.Subscriber, and an error if no subscription
+// succeeded.
+func (r *Redsync) Subscribe(ctx context.Context, pattern string) (<-chan MutexEvent, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var (
+		subs []<-chan redis.Message
+		err  error
+	)
+	for _, pool := range r.pools {
+		subscriber, ok := pool.(redis.Subscriber)
+		if !ok {
+			continue
+		}
+		msgs, e := subscriber.PSubscribe(ctx, eventsChannelPrefix+pattern)
+		if e != nil {
+			err = e
+			continue
+		}
+		subs = append(subs, msgs)
+	}
+	if len(subs) == 0 {
+		if err == nil {
+			err = ErrSubscribeUnsupported
+		}
+		return nil, err
+	}
+
+	events := make(chan MutexEvent)
+	seen := newSeenSet(seenEventsSize)
+	var wg sync.WaitGroup
+	for _, msgs := range subs {
+		wg.Add(1)
+		go func(msgs <-chan redis.Message) {
+			defer wg.Done()
+			for msg := range msgs {
+				var event MutexEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil || !seen.add(event.ID) {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(msgs)
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	return events, nil
+}
+
+// A seenSet holds the most recently added IDs, up to a fixed number.
+type seenSet struct {
+	mu   sync.Mutex
+	ids  map[string]struct{}
+	ring []string
+	next int
+}
+
+func newSeenSet(size int) *seenSet {
+	return &seenSet{ids: make(map[string]struct{}, size), ring: make([]string, size)}
+}
+
+// add adds id and returns whether it was not already in the set.
+func (s *seenSet) add(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ids[id]; ok {
+		return false
+	}
+	delete(s.ids, s.ring[s.next])
+	s.ring[s.next] = id
+	s.next = (s.next + 1) % len(s.ring)
+	s.ids[id] = struct{}{}
+	return true
+}
@@ -0,0 +1,41 @@
+package redsync
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// An ExtensionEvent describes an attempt to extend a lock, as received by the listener set with
+// WithExtensionListener.
+type ExtensionEvent struct {
+	// Attempt is the number of the attempt, starting at 1, since the lock was acquired.
+	Attempt int
+	Success bool
+	Error   error
+	// RemainingTTL is the remaining validity of the lock after the attempt. It is zero if the lock has expired.
+	RemainingTTL time.Duration
+	Timestamp    time.Time
+}
+
+// notifyExtensionListener sends the outcome of an attempt to extend the lock to the extension listener of m, if any.
+func (m *Mutex) notifyExtensionListener(ok bool, err error) {
+	if m.extensionListener == nil {
+		return
+	}
+	now := time.Now()
+	remaining := m.Until().Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	event := ExtensionEvent{
+		Attempt:      int(atomic.AddInt64(&m.extendAttempts, 1)),
+		Success:      ok,
+		Error:        err,
+		RemainingTTL: remaining,
+		Timestamp:    now,
+	}
+	select {
+	case m.extensionListener <- event:
+	default:
+	}
+}
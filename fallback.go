@@ -0,0 +1,73 @@
+package redsync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// localLocks holds the in-process locks used by mutexes with the WithLocalFallback option, by mutex name.
+var localLocks sync.Map
+
+// localLock returns the in-process lock for the mutex with the given name. The lock is held while the channel is full.
+func localLock(name string) chan struct{} {
+	ch, _ := localLocks.LoadOrStore(name, make(chan struct{}, 1))
+	return ch.(chan struct{})
+}
+
+// WithLockToggle can be used to enable or disable distributed locking dynamically, for instance from a feature flag
+// during a Redis outage. enabled is called on each lock. If it returns false, the lock is not taken in Redis: the mutex
+// falls back to an in-process lock if the WithLocalFallback option is used, and to a no-op lock otherwise.
+func WithLockToggle(enabled func(ctx context.Context) bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.lockEnabled = enabled
+	})
+}
+
+// WithLocalFallback can be used to fall back to an in-process lock, shared by the mutexes with the same name in the
+// process, when distributed locking is disabled with WithLockToggle. The in-process lock does not expire. Without this
+// option, locking always succeeds immediately while distributed locking is disabled.
+func WithLocalFallback(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.localFallback = b
+	})
+}
+
+// bypassLock reports whether distributed locking is disabled for m.
+func (m *Mutex) bypassLock(ctx context.Context) bool {
+	return m.lockEnabled != nil && !m.lockEnabled(ctx)
+}
+
+// lockLocally acquires the lock of m without Redis. If tries is 1, it does not wait for the in-process lock.
+func (m *Mutex) lockLocally(ctx context.Context, tries int) error {
+	if m.localFallback {
+		ch := localLock(m.name)
+		if tries == 1 {
+			select {
+			case ch <- struct{}{}:
+			default:
+				return ErrFailed
+			}
+		} else {
+			select {
+			case ch <- struct{}{}:
+			case <-ctx.Done():
+				return ErrFailed
+			}
+		}
+	}
+	m.setBypassed(true)
+	m.setUntil(time.Now().Add(m.getExpiry()))
+	return nil
+}
+
+// unlockLocally releases the lock of m acquired by lockLocally.
+func (m *Mutex) unlockLocally() {
+	if m.localFallback {
+		select {
+		case <-localLock(m.name):
+		default:
+		}
+	}
+	m.setBypassed(false)
+}
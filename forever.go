@@ -0,0 +1,80 @@
+package redsync
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// forever is the time of validity of locks acquired with LockForever.
+var forever = time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+var persistScript = redis.NewScript(1, `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("PERSIST", KEYS[1])
+	end
+	return 0
+`)
+
+var forceDeleteScript = redis.NewScript(1, `
+	redis.call("DEL", KEYS[1])
+	return 1
+`)
+
+// LockForever locks m and removes the expiry of the lock with PERSIST, so that it is held until Unlock is called.
+// Extend is a no-op while the lock is held forever.
+//
+// Use with great care: if the process crashes or otherwise fails to call Unlock, the lock is held permanently and can
+// only be recovered with ForceUnlock. It is meant for one-time operations, such as migrations or schema changes, where
+// the lock expiring while the operation runs would be worse.
+func (m *Mutex) LockForever(ctx context.Context) error {
+	if err := m.LockContext(ctx); err != nil {
+		return err
+	}
+	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer conn.Close()
+		reply, err := conn.Eval(persistScript, m.name, m.Value())
+		return replyInt64(reply) == 1, err
+	})
+	if n < m.heldQuorum() {
+		_, _ = m.UnlockContext(ctx)
+		if err == nil {
+			err = ErrFailed
+		}
+		return err
+	}
+	m.setPersistent()
+	return nil
+}
+
+// ForceUnlock deletes the key of m on all pools, whoever holds the lock, and returns true if it was deleted on a
+// quorum of pools. It is meant to recover locks acquired with LockForever by a process that died; it breaks mutual
+// exclusion if the lock is still in use. If m itself held the lock, m is released as if by Unlock and can be locked
+// again.
+func (m *Mutex) ForceUnlock(ctx context.Context) (bool, error) {
+	_, held := m.heldValue()
+	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer conn.Close()
+		_, err = conn.Eval(forceDeleteScript, m.name)
+		return err == nil, err
+	})
+	if n < m.quorum {
+		return false, err
+	}
+	if held {
+		m.stopReleaseTimer()
+		m.setReleased()
+		m.endTransition(StateUnlocked)
+		m.cancelAcquiredContexts(context.Canceled)
+	}
+	return true, nil
+}
@@ -0,0 +1,161 @@
+package redsync
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// A MutexGroup caches the mutexes of a Redsync instance by name, so that all the callers of Get with the same name share
+// a single Mutex. By default mutexes are cached indefinitely; SetMaxAge and SetMaxSize bound the cache for services
+// that use many short-lived lock names.
+//
+// Evicting a mutex only removes it from the cache: callers that still hold a reference can keep using it, and the next
+// call to Get with its name returns a new Mutex.
+type MutexGroup struct {
+	rs      *Redsync
+	options []Option
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List // Front is the most recently used entry.
+	maxAge   time.Duration
+	maxSize  int
+	interval time.Duration
+	stop     chan struct{}
+}
+
+type groupEntry struct {
+	name     string
+	mutex    *Mutex
+	lastUsed time.Time
+}
+
+// NewMutexGroup returns a new group of mutexes created with the given options.
+func (r *Redsync) NewMutexGroup(options ...Option) *MutexGroup {
+	return &MutexGroup{
+		rs:      r,
+		options: options,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Get returns the mutex with the given name, creating it if it is not cached.
+func (g *MutexGroup) Get(name string) *Mutex {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if g.interval == 0 {
+		g.evictExpired(now)
+	}
+	if elem, ok := g.entries[name]; ok {
+		entry := elem.Value.(*groupEntry)
+		entry.lastUsed = now
+		g.lru.MoveToFront(elem)
+		return entry.mutex
+	}
+
+	m := g.rs.NewMutex(name, g.options...)
+	g.entries[name] = g.lru.PushFront(&groupEntry{name: name, mutex: m, lastUsed: now})
+	g.evictOverflow()
+	return m
+}
+
+// Len returns the number of mutexes cached in g.
+func (g *MutexGroup) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.lru.Len()
+}
+
+// SetMaxAge sets the time after which a mutex that has not been returned by Get is evicted. Zero, the default, disables
+// age-based eviction.
+func (g *MutexGroup) SetMaxAge(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.maxAge = d
+}
+
+// SetMaxSize sets the maximum number of mutexes cached in g. When it is exceeded, the least recently used mutexes are
+// evicted. Zero, the default, disables size-based eviction.
+func (g *MutexGroup) SetMaxSize(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.maxSize = n
+	g.evictOverflow()
+}
+
+// SetEvictionInterval controls when mutexes older than the maximum age are evicted. If d is zero, the default, they are
+// evicted lazily on the next call to Get. Otherwise they are evicted by a background goroutine every d, which runs until
+// Close is called.
+func (g *MutexGroup) SetEvictionInterval(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopEvictor()
+	g.interval = d
+	if d > 0 {
+		g.stop = make(chan struct{})
+		go g.runEvictor(d, g.stop)
+	}
+}
+
+// Close stops the background eviction goroutine, if any. The group remains usable, with lazy eviction.
+func (g *MutexGroup) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopEvictor()
+	g.interval = 0
+}
+
+func (g *MutexGroup) stopEvictor() {
+	if g.stop != nil {
+		close(g.stop)
+		g.stop = nil
+	}
+}
+
+func (g *MutexGroup) runEvictor(d time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			g.mu.Lock()
+			g.evictExpired(now)
+			g.mu.Unlock()
+		}
+	}
+}
+
+// evictExpired evicts the mutexes that have not been used for longer than the maximum age. g.mu must be held.
+func (g *MutexGroup) evictExpired(now time.Time) {
+	if g.maxAge <= 0 {
+		return
+	}
+	for elem := g.lru.Back(); elem != nil; elem = g.lru.Back() {
+		entry := elem.Value.(*groupEntry)
+		if now.Sub(entry.lastUsed) < g.maxAge {
+			return
+		}
+		g.remove(elem)
+	}
+}
+
+// evictOverflow evicts the least recently used mutexes beyond the maximum size. g.mu must be held.
+func (g *MutexGroup) evictOverflow() {
+	if g.maxSize <= 0 {
+		return
+	}
+	for g.lru.Len() > g.maxSize {
+		g.remove(g.lru.Back())
+	}
+}
+
+func (g *MutexGroup) remove(elem *list.Element) {
+	g.lru.Remove(elem)
+	delete(g.entries, elem.Value.(*groupEntry).name)
+}
@@ -0,0 +1,55 @@
+package redsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis/memory"
+)
+
+func TestMutexGroup(t *testing.T) {
+	g := New(memory.NewPool()).NewMutexGroup(WithTries(1))
+	m := g.Get("test-mutex-group")
+	if g.Get("test-mutex-group") != m {
+		t.Fatalf("Expected the cached mutex to be returned")
+	}
+	if m.tries != 1 {
+		t.Fatalf("Expected tries = 1, got %d", m.tries)
+	}
+}
+
+func TestMutexGroupMaxSize(t *testing.T) {
+	g := New(memory.NewPool()).NewMutexGroup()
+	g.SetMaxSize(2)
+	a := g.Get("a")
+	g.Get("b")
+	g.Get("a")
+	g.Get("c")
+	if n := g.Len(); n != 2 {
+		t.Fatalf("Expected 2 cached mutexes, got %d", n)
+	}
+	if g.Get("a") != a {
+		t.Fatalf("Expected the recently used mutex to be kept")
+	}
+	if _, ok := g.entries["b"]; ok {
+		t.Fatalf("Expected the least recently used mutex to be evicted")
+	}
+}
+
+func TestMutexGroupMaxAge(t *testing.T) {
+	g := New(memory.NewPool()).NewMutexGroup()
+	g.SetMaxAge(50 * time.Millisecond)
+	a := g.Get("a")
+	time.Sleep(100 * time.Millisecond)
+	if g.Get("a") == a {
+		t.Fatalf("Expected the expired mutex to be evicted lazily")
+	}
+
+	g.SetEvictionInterval(10 * time.Millisecond)
+	defer g.Close()
+	g.Get("b")
+	time.Sleep(100 * time.Millisecond)
+	if n := g.Len(); n != 0 {
+		t.Fatalf("Expected expired mutexes to be evicted, got %d cached", n)
+	}
+}
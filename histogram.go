@@ -0,0 +1,60 @@
+package redsync
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the buckets of the built-in latency histogram.
+var latencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// A BucketCount is the number of lock acquisitions whose latency fell in a bucket of the built-in latency histogram,
+// that is greater than the upper bound of the previous bucket and less than or equal to UpperBound.
+type BucketCount struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// latencyHistogram counts latencies in latencyBuckets, plus a final bucket for latencies above 5s.
+type latencyHistogram struct {
+	counts [len(latencyBuckets) + 1]atomic.Uint64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i := 0
+	for i < len(latencyBuckets) && d > latencyBuckets[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+}
+
+// LatencyHistogram returns the number of successful lock acquisitions of m per latency bucket, as recorded with the
+// WithBuiltinHistogram option. Latency is measured from the call to Lock until the lock is acquired, including retries.
+// The last bucket, with an UpperBound of math.MaxInt64, counts latencies above 5s. It returns nil if the option is not
+// used.
+func (m *Mutex) LatencyHistogram() []BucketCount {
+	if m.histogram == nil {
+		return nil
+	}
+	counts := make([]BucketCount, len(m.histogram.counts))
+	for i := range counts {
+		counts[i].UpperBound = math.MaxInt64
+		if i < len(latencyBuckets) {
+			counts[i].UpperBound = latencyBuckets[i]
+		}
+		counts[i].Count = m.histogram.counts[i].Load()
+	}
+	return counts
+}
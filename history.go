@@ -0,0 +1,85 @@
+package redsync
+
+import (
+	"sync"
+	"time"
+)
+
+// A LockEventType is the kind of a LockEvent.
+type LockEventType int
+
+const (
+	// EventLock is recorded when a call to lock completes, successfully or not.
+	EventLock LockEventType = iota
+	// EventUnlock is recorded when a call to unlock completes, successfully or not.
+	EventUnlock
+	// EventExtend is recorded when a call to extend completes, successfully or not.
+	EventExtend
+	// EventExpire is recorded when the lock is found to have expired.
+	EventExpire
+	// EventRetry is recorded when an attempt to acquire the lock fails and is going to be retried.
+	EventRetry
+)
+
+func (t LockEventType) String() string {
+	switch t {
+	case EventLock:
+		return "lock"
+	case EventUnlock:
+		return "unlock"
+	case EventExtend:
+		return "extend"
+	case EventExpire:
+		return "expire"
+	case EventRetry:
+		return "retry"
+	}
+	return "unknown"
+}
+
+// A LockEvent is an entry of the history of a mutex, as recorded with the WithHistory option.
+type LockEvent struct {
+	Time time.Time
+	Type LockEventType
+	// Attempt is the number of attempts made to acquire the lock, for EventLock and EventRetry events.
+	Attempt int
+	// Err is the error the operation failed with, if any.
+	Err error
+}
+
+// lockHistory is a ring buffer of the most recent events of a mutex. It is safe for concurrent use.
+type lockHistory struct {
+	mu     sync.Mutex
+	events []LockEvent
+	next   int
+	full   bool
+}
+
+// record adds an event to h. It is a no-op if h is nil.
+func (h *lockHistory) record(t LockEventType, attempt int, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events[h.next] = LockEvent{Time: time.Now(), Type: t, Attempt: attempt, Err: err}
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// History returns the most recent events of m, oldest first, as recorded with the WithHistory option. It returns nil
+// if the option is not used.
+func (m *Mutex) History() []LockEvent {
+	h := m.history
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]LockEvent(nil), h.events[:h.next]...)
+	}
+	return append(append([]LockEvent(nil), h.events[h.next:]...), h.events[:h.next]...)
+}
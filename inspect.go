@@ -0,0 +1,190 @@
+package redsync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/hashicorp/go-multierror"
+)
+
+// A PoolStatus describes the state of a mutex's key in one of the Redis pools.
+type PoolStatus struct {
+	// Index is the position of the pool in the list of pools the mutex was created with.
+	Index int
+	// Reachable is false if the pool could not be queried.
+	Reachable bool
+	// KeyExists is true if the mutex's key is present in the pool.
+	KeyExists bool
+	// Value is the value stored in the mutex's key.
+	Value string
+	// TTL is the remaining time to live of the key. It is zero if the key does not exist and negative if the key has
+	// no expiry.
+	TTL time.Duration
+	// ValueMatches is true if Value is the value of the mutex.
+	ValueMatches bool
+}
+
+var inspectScript = redis.NewScript(1, `
+	return {redis.call("GET", KEYS[1]) or "", redis.call("PTTL", KEYS[1])}
+`)
+
+// InspectPools returns the state of m's key in each of its pools. This is a diagnostic tool for quorum disagreements,
+// split-brain and partial-failure scenarios. A status is returned for every pool; the error lists the pools that could
+// not be reached.
+func (m *Mutex) InspectPools(ctx context.Context) ([]PoolStatus, error) {
+	statuses := make([]PoolStatus, len(m.pools))
+	errs := make([]error, len(m.pools))
+
+	var wg sync.WaitGroup
+	for node, pool := range m.pools {
+		wg.Add(1)
+		go func(node int, pool redis.Pool) {
+			defer wg.Done()
+			statuses[node], errs[node] = m.inspect(ctx, node, pool)
+		}(node, pool)
+	}
+	wg.Wait()
+
+	var err error
+	for node, e := range errs {
+		if e != nil {
+			err = multierror.Append(err, &RedisError{Node: node, Err: e})
+		}
+	}
+	return statuses, err
+}
+
+func (m *Mutex) inspect(ctx context.Context, node int, pool redis.Pool) (PoolStatus, error) {
+	status := PoolStatus{Index: node}
+	conn, err := pool.Get(ctx)
+	if err != nil {
+		return status, err
+	}
+	defer conn.Close()
+	reply, err := conn.Eval(inspectScript, m.name)
+	if err != nil {
+		return status, err
+	}
+	values := replySlice(reply)
+	if len(values) != 2 {
+		return status, errUnexpectedReply
+	}
+	status.Reachable = true
+	status.Value = replyString(values[0])
+	switch pttl := replyInt64(values[1]); pttl {
+	case -2:
+	case -1:
+		status.KeyExists = true
+		status.TTL = -1
+	default:
+		status.KeyExists = true
+		status.TTL = time.Duration(pttl) * time.Millisecond
+	}
+	status.ValueMatches = status.KeyExists && m.Value() != "" && status.Value == m.Value()
+	return status, nil
+}
+
+// A MutexSnapshot captures the state of a mutex and of its key in each of its pools at a point in time. Snapshots are
+// values and can be compared in test assertions, for example with redsynctest.AssertSnapshotEqual.
+type MutexSnapshot struct {
+	Name  string
+	Value string
+	// TTL is the smallest remaining time to live of the key among the pools holding m's lock, or zero if the lock is
+	// not held.
+	TTL time.Duration
+	// HeldByUs is true if a quorum of pools holds the mutex's value.
+	HeldByUs bool
+	Pools    []PoolStatus
+}
+
+// Snapshot captures the current state of m. See SnapshotContext.
+func (m *Mutex) Snapshot() (MutexSnapshot, error) {
+	return m.SnapshotContext(context.Background())
+}
+
+// SnapshotContext captures the current state of m, querying each of its pools. If some pools cannot be reached, the
+// snapshot is returned along with the error.
+func (m *Mutex) SnapshotContext(ctx context.Context) (MutexSnapshot, error) {
+	statuses, err := m.InspectPools(ctx)
+	snapshot := MutexSnapshot{
+		Name:  m.name,
+		Value: m.Value(),
+		Pools: statuses,
+	}
+
+	n := 0
+	for _, status := range statuses {
+		if !status.ValueMatches {
+			continue
+		}
+		n++
+		if snapshot.TTL == 0 || (status.TTL > 0 && status.TTL < snapshot.TTL) {
+			snapshot.TTL = status.TTL
+		}
+	}
+	snapshot.HeldByUs = n >= m.quorum
+	if !snapshot.HeldByUs {
+		snapshot.TTL = 0
+	}
+	return snapshot, err
+}
+
+var encodingScript = redis.NewScript(1, `
+	return redis.call("OBJECT", "ENCODING", KEYS[1]) or ""
+`)
+
+// KeyEncoding returns the internal encoding Redis uses for m's key, such as "embstr" or "raw", as reported by OBJECT
+// ENCODING by the majority of the pools. It returns an empty string if the key does not exist on most pools. It is a
+// diagnostic tool for performance investigations and requires a quorum of pools to reply.
+func (m *Mutex) KeyEncoding(ctx context.Context) (string, error) {
+	type result struct {
+		node     int
+		encoding string
+		err      error
+	}
+
+	ch := make(chan result, len(m.pools))
+	for node, pool := range m.pools {
+		go func(node int, pool redis.Pool) {
+			r := result{node: node}
+			conn, err := pool.Get(ctx)
+			if err != nil {
+				r.err = err
+				ch <- r
+				return
+			}
+			defer conn.Close()
+			reply, err := conn.Eval(encodingScript, m.name)
+			r.encoding, r.err = replyString(reply), err
+			ch <- r
+		}(node, pool)
+	}
+
+	var (
+		n      = 0
+		counts = map[string]int{}
+		err    error
+	)
+	for range m.pools {
+		r := <-ch
+		if r.err != nil {
+			err = multierror.Append(err, &RedisError{Node: r.node, Err: r.err})
+			continue
+		}
+		n++
+		counts[r.encoding]++
+	}
+	if n < m.quorum {
+		return "", err
+	}
+
+	var encoding string
+	for e, c := range counts {
+		if c > counts[encoding] || (c == counts[encoding] && e < encoding) {
+			encoding = e
+		}
+	}
+	return encoding, nil
+}
@@ -0,0 +1,145 @@
+package redsync
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// A LastWriteWinsMutex is a lock with inverted semantics: locking always succeeds and takes the lock over from its
+// current holder, if any. The most recent caller of Lock owns the lock until it expires or another caller locks it;
+// previous holders can find out with IsOwner that they were superseded. It is useful for job deduplication and leader
+// election where the most recently active worker should win.
+//
+// It provides no mutual exclusion: a holder must check IsOwner before acting on behalf of the lock.
+type LastWriteWinsMutex struct {
+	m *Mutex
+}
+
+// NewLastWriteWinsMutex returns a new last-writer-wins lock with given name. Options related to retries do not apply,
+// as locking never waits.
+func (r *Redsync) NewLastWriteWinsMutex(name string, options ...Option) *LastWriteWinsMutex {
+	return &LastWriteWinsMutex{m: r.NewMutex(name, options...)}
+}
+
+var setScript = redis.NewScript(1, `
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+`)
+
+// Name returns mutex name (i.e. the Redis key).
+func (l *LastWriteWinsMutex) Name() string {
+	return l.m.name
+}
+
+// Value returns the current random value. The value will be empty until a lock is acquired.
+func (l *LastWriteWinsMutex) Value() string {
+	return l.m.Value()
+}
+
+// Until returns the time of validity of acquired lock. The value will be zero value until a lock is acquired.
+func (l *LastWriteWinsMutex) Until() time.Time {
+	return l.m.Until()
+}
+
+// Lock takes the lock over, replacing the value set by its current holder, if any.
+func (l *LastWriteWinsMutex) Lock() error {
+	return l.LockContext(context.Background())
+}
+
+// LockContext takes the lock over, replacing the value set by its current holder, if any. It only fails if the lock
+// could not be set on a quorum of pools.
+func (l *LastWriteWinsMutex) LockContext(ctx context.Context) error {
+	m := l.m
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	value, err := m.genValueFunc()
+	if err != nil {
+		return err
+	}
+
+	expiry := m.getExpiry()
+	start := time.Now()
+	n, err := func() (int, error) {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(int64(float64(expiry)*m.timeoutFactor)))
+		defer cancel()
+		return m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+			conn, err := pool.Get(ctx)
+			if err != nil {
+				return false, err
+			}
+			defer conn.Close()
+			reply, err := conn.Eval(setScript, m.name, value, int(expiry/time.Millisecond))
+			if err != nil {
+				return false, err
+			}
+			return replyInt64(reply) == 1, nil
+		})
+	}()
+	now := time.Now()
+	until := now.Add(expiry - now.Sub(start) - time.Duration(int64(float64(expiry)*m.driftFactor)))
+	if n < m.quorum || !now.Before(until) {
+		if err == nil {
+			err = ErrFailed
+		}
+		return err
+	}
+	m.setLockState(value, until, now, false)
+	return nil
+}
+
+// IsOwner returns whether the lock is still held with the value set by the last call to Lock, that is whether no
+// other caller has taken it over and it has not expired.
+func (l *LastWriteWinsMutex) IsOwner() (bool, error) {
+	return l.IsOwnerContext(context.Background())
+}
+
+// IsOwnerContext returns whether the lock is still held with the value set by the last call to Lock, that is whether
+// no other caller has taken it over and it has not expired.
+func (l *LastWriteWinsMutex) IsOwnerContext(ctx context.Context) (bool, error) {
+	m := l.m
+	if m.Value() == "" {
+		return false, nil
+	}
+	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer conn.Close()
+		value, err := conn.Get(m.name)
+		if err != nil {
+			return false, err
+		}
+		return value == m.Value(), nil
+	})
+	if n < m.quorum {
+		// A pool holding another value is not an error: the lock was taken over.
+		var redisErr *RedisError
+		if errors.As(err, &redisErr) {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// Unlock releases the lock if it is still owned and returns the status of unlock.
+func (l *LastWriteWinsMutex) Unlock() (bool, error) {
+	return l.UnlockContext(context.Background())
+}
+
+// UnlockContext releases the lock if it is still owned and returns the status of unlock.
+func (l *LastWriteWinsMutex) UnlockContext(ctx context.Context) (bool, error) {
+	m := l.m
+	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		return m.release(ctx, pool, m.Value())
+	})
+	if n < m.quorum {
+		return false, err
+	}
+	return true, nil
+}
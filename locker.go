@@ -0,0 +1,19 @@
+package redsync
+
+import "context"
+
+// A Locker is a distributed lock. It is implemented by *Mutex, and can be used in place of it so that code using a
+// lock can be tested with a test double such as redsynctest.TestMutex.
+type Locker interface {
+	Name() string
+	Lock() error
+	LockContext(ctx context.Context) error
+	TryLock() error
+	TryLockContext(ctx context.Context) error
+	Unlock() (bool, error)
+	UnlockContext(ctx context.Context) (bool, error)
+	Extend() (bool, error)
+	ExtendContext(ctx context.Context) (bool, error)
+}
+
+var _ Locker = (*Mutex)(nil)
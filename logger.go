@@ -0,0 +1,54 @@
+package redsync
+
+import (
+	"errors"
+)
+
+// A logger receives structured log records of mutex operations. It is satisfied by *slog.Logger; see WithSlogLogger.
+// args are alternating keys and values.
+type logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+func (m *Mutex) logLock(value string, attempts int, err error) {
+	if m.logger == nil {
+		return
+	}
+	if err != nil {
+		m.logFailure("lock", err, "attempts", attempts)
+		return
+	}
+	m.logger.Info("redsync: lock acquired", "name", m.name, "value", value, "attempts", attempts)
+}
+
+func (m *Mutex) logRetry(attempt int, err error) {
+	if m.logger == nil {
+		return
+	}
+	m.logger.Debug("redsync: retrying lock", "name", m.name, "attempt", attempt, "error", err)
+}
+
+func (m *Mutex) logRelease() {
+	if m.logger == nil {
+		return
+	}
+	m.logger.Info("redsync: lock released", "name", m.name)
+}
+
+// logFailure logs a failed operation as an error if a Redis node could not be reached or returned an error, and as a
+// warning if the operation failed because a quorum was not reached.
+func (m *Mutex) logFailure(op string, err error, args ...any) {
+	if m.logger == nil {
+		return
+	}
+	args = append([]any{"name", m.name, "error", err}, args...)
+	var redisErr *RedisError
+	if errors.As(err, &redisErr) {
+		m.logger.Error("redsync: "+op+" failed", args...)
+	} else {
+		m.logger.Warn("redsync: "+op+" failed to reach quorum", args...)
+	}
+}
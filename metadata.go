@@ -0,0 +1,126 @@
+package redsync
+
+import (
+	"context"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// metadataScript sets fields of the metadata hash KEYS[2] of the lock KEYS[1] if it is held with value ARGV[1], and
+// gives the hash the remaining TTL of the lock. If ARGV[2] is "1", the hash is cleared first.
+var metadataScript = redis.NewScript(2, `
+	if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+		return 0
+	end
+	local meta = KEYS[2]
+	if ARGV[2] == "1" then
+		redis.call("DEL", meta)
+	end
+	for i = 3, #ARGV, 2 do
+		redis.call("HSET", meta, ARGV[i], ARGV[i + 1])
+	end
+	local ttl = redis.call("PTTL", KEYS[1])
+	if ttl > 0 then
+		redis.call("PEXPIRE", meta, ttl)
+	end
+	return 1
+`)
+
+var metadataDeleteScript = redis.NewScript(2, `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[2])
+	end
+	return 0
+`)
+
+// UpdateTags sets the given fields of the metadata hash of the lock held by m, as created with the WithMetadataHash
+// option, without affecting the value or TTL of the lock. Fields not in tags are left unchanged. The updated tags are
+// also written by the subsequent locks of m. It returns ErrLockNotHeld if the hash could not be updated on a quorum of
+// pools.
+func (m *Mutex) UpdateTags(ctx context.Context, tags map[string]string) error {
+	if m.Value() == "" {
+		return ErrLockNotHeld
+	}
+	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		return m.setMetadata(ctx, pool, false, tags)
+	})
+	if n < m.heldQuorum() {
+		if err == nil {
+			err = ErrLockNotHeld
+		}
+		return err
+	}
+	if m.metadata == nil {
+		m.metadata = make(map[string]string, len(tags))
+	}
+	for k, v := range tags {
+		m.metadata[k] = v
+	}
+	return nil
+}
+
+// writeMetadata replaces the metadata hash of the lock held by m with the tags given to WithMetadataHash, if any.
+// Metadata updates are best effort and never cause a lock to fail.
+func (m *Mutex) writeMetadata(ctx context.Context) {
+	if m.metadata == nil {
+		return
+	}
+	_, _ = m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		return m.setMetadata(ctx, pool, true, m.metadata)
+	})
+}
+
+// touchMetadata gives the metadata hash of the lock held by m the lock's new TTL after an extension.
+func (m *Mutex) touchMetadata(ctx context.Context) {
+	if m.metadata == nil {
+		return
+	}
+	_, _ = m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		return m.setMetadata(ctx, pool, false, nil)
+	})
+}
+
+// deleteMetadata deletes the metadata hash of the lock held by m. It must be called before the lock is released, so
+// that the hash of a subsequent holder is never deleted.
+func (m *Mutex) deleteMetadata(ctx context.Context) {
+	if m.metadata == nil {
+		return
+	}
+	_, _ = m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer conn.Close()
+		_, err = conn.Eval(metadataDeleteScript, m.name, m.metadataKey(), m.Value())
+		return err == nil, err
+	})
+}
+
+// metadataKey returns the key of the metadata hash of the lock held by m.
+func (m *Mutex) metadataKey() string {
+	return m.name + ":meta"
+}
+
+func (m *Mutex) setMetadata(ctx context.Context, pool redis.Pool, reset bool, tags map[string]string) (bool, error) {
+	conn, err := pool.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	resetArg := "0"
+	if reset {
+		resetArg = "1"
+	}
+	keysAndArgs := make([]interface{}, 0, 4+2*len(tags))
+	keysAndArgs = append(keysAndArgs, m.name, m.metadataKey(), m.Value(), resetArg)
+	for k, v := range tags {
+		keysAndArgs = append(keysAndArgs, k, v)
+	}
+	reply, err := conn.Eval(metadataScript, keysAndArgs...)
+	if err != nil {
+		return false, err
+	}
+	return replyInt64(reply) == 1, nil
+}
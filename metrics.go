@@ -0,0 +1,91 @@
+package redsync
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// metricsSamples is the number of recent acquisition latencies P99AcquisitionLatencyNs is computed from.
+const metricsSamples = 1024
+
+// A MutexMetrics is a snapshot of the statistics of a mutex.
+type MutexMetrics struct {
+	TotalLocks    uint64
+	TotalUnlocks  uint64
+	TotalExtends  uint64
+	FailedLocks   uint64
+	FailedExtends uint64
+	// AvgAcquisitionLatencyNs is the average time taken by successful lock acquisitions, in nanoseconds.
+	AvgAcquisitionLatencyNs int64
+	// P99AcquisitionLatencyNs is the 99th percentile of the time taken by the last 1024 successful lock acquisitions,
+	// in nanoseconds.
+	P99AcquisitionLatencyNs int64
+}
+
+// mutexMetrics accumulates the statistics of a mutex. It is safe for concurrent use.
+type mutexMetrics struct {
+	mu         sync.Mutex
+	snapshot   MutexMetrics
+	latencySum int64
+	samples    [metricsSamples]int64
+	next       int
+	full       bool
+}
+
+func (s *mutexMetrics) recordLock(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.snapshot.FailedLocks++
+		return
+	}
+	s.snapshot.TotalLocks++
+	s.latencySum += int64(latency)
+	s.samples[s.next] = int64(latency)
+	s.next = (s.next + 1) % metricsSamples
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+func (s *mutexMetrics) recordUnlock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot.TotalUnlocks++
+}
+
+func (s *mutexMetrics) recordExtend(ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ok {
+		s.snapshot.TotalExtends++
+	} else {
+		s.snapshot.FailedExtends++
+	}
+}
+
+// Metrics returns a consistent snapshot of the statistics of m: the numbers of successful and failed lock, unlock and
+// extend operations, and the latency of lock acquisitions, including retries.
+func (m *Mutex) Metrics() MutexMetrics {
+	s := &m.metrics
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics := s.snapshot
+	if metrics.TotalLocks > 0 {
+		metrics.AvgAcquisitionLatencyNs = s.latencySum / int64(metrics.TotalLocks)
+	}
+	n := s.next
+	if s.full {
+		n = metricsSamples
+	}
+	if n > 0 {
+		samples := append([]int64(nil), s.samples[:n]...)
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i] < samples[j]
+		})
+		metrics.P99AcquisitionLatencyNs = samples[(n*99+99)/100-1]
+	}
+	return metrics
+}
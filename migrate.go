@@ -0,0 +1,115 @@
+package redsync
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// renameScript renames the lock KEYS[1] to ARGV[2] if it is held with value ARGV[1]. The new name is passed in ARGV
+// rather than KEYS, as some clients refuse multi-key commands whose keys hash to different slots.
+var renameScript = redis.NewScript(1, `
+	if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+		return 0
+	end
+	redis.call("RENAME", KEYS[1], ARGV[2])
+	return 1
+`)
+
+// copyScript is like renameScript, but uses COPY and DEL so that an existing key named ARGV[2] is never overwritten,
+// in which case it returns -1. On servers without COPY, it falls back to RENAME and returns 2.
+var copyScript = redis.NewScript(1, `
+	if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+		return 0
+	end
+	local copied = redis.pcall("COPY", KEYS[1], ARGV[2])
+	if type(copied) == "table" and copied.err then
+		redis.call("RENAME", KEYS[1], ARGV[2])
+		return 2
+	end
+	if copied == 0 then
+		return -1
+	end
+	redis.call("DEL", KEYS[1])
+	return 1
+`)
+
+// MigrateKey moves the lock held by m to the key newName, keeping its value and TTL, and renames m accordingly. The
+// lock must be held on a quorum of pools. If it could not be moved on a quorum of pools, the pools it was moved on are
+// moved back, so that the lock remains held under its previous name.
+//
+// Only the lock key is moved: the companion keys of the lock, which are its version counter (name:version), its wait
+// queue (name:queue) and its metadata hash (name:meta), as well as its registry entries, if any, are not moved.
+//
+// By default the key is moved with RENAME, which overwrites any existing key named newName. With the WithSafeRename
+// option, MigrateKey returns ErrKeyExists instead.
+func (m *Mutex) MigrateKey(ctx context.Context, newName string) error {
+	value := m.Value()
+	if value == "" {
+		return ErrLockNotHeld
+	}
+
+	var (
+		mu    sync.Mutex
+		moved []redis.Pool
+	)
+	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		ok, err := m.migrate(ctx, pool, newName)
+		if ok {
+			mu.Lock()
+			moved = append(moved, pool)
+			mu.Unlock()
+		}
+		return ok, err
+	})
+	if n < m.heldQuorum() {
+		rollbackCtx := context.WithoutCancel(ctx)
+		for _, pool := range moved {
+			_, _ = m.rename(rollbackCtx, pool, renameScript, newName, value, m.name)
+		}
+		if err == nil {
+			err = ErrLockNotHeld
+		}
+		return err
+	}
+	m.name = newName
+	return nil
+}
+
+func (m *Mutex) migrate(ctx context.Context, pool redis.Pool, newName string) (bool, error) {
+	script := renameScript
+	if m.safeRename {
+		script = copyScript
+	}
+	reply, err := m.rename(ctx, pool, script, m.name, m.Value(), newName)
+	if err != nil {
+		return false, err
+	}
+	switch reply {
+	case -1:
+		return false, ErrKeyExists
+	case 2:
+		if m.logger != nil {
+			m.logger.Warn("redsync: COPY is not supported, falling back to RENAME", "name", m.name, "new_name", newName)
+		}
+		return true, nil
+	case 1:
+		return true, nil
+	}
+	return false, nil
+}
+
+// rename evaluates script, renameScript or copyScript, to move the lock held with value from name to newName on pool.
+func (m *Mutex) rename(ctx context.Context, pool redis.Pool, script *redis.Script, name, value, newName string) (int64, error) {
+	conn, err := pool.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	reply, err := conn.Eval(script, name, value, newName)
+	if err != nil {
+		return 0, err
+	}
+	return replyInt64(reply), nil
+}
@@ -4,10 +4,18 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
+	mathrand "math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redsync/redsync/v4/redis"
 	"github.com/hashicorp/go-multierror"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
 )
 
 // A DelayFunc is used to decide the amount of time to wait between retries.
@@ -27,13 +35,74 @@ type Mutex struct {
 	quorum int
 
 	genValueFunc  func() (string, error)
-	value         string
-	until         time.Time
 	shuffle       bool
 	failFast      bool
 	setNXOnExtend bool
 
+	stateMachine bool
+	state        MutexState
+
+	stickyPool bool
+	stickyNode int
+
+	registry *LockRegistry
+
+	reentrantKey interface{}
+
+	versionedLock bool
+
+	commandDedup bool
+
+	acquireLimiter *rate.Limiter
+
+	serverSideTime bool
+
+	observer RedsyncObserver
+
+	metadata map[string]string
+
+	histogram   *latencyHistogram
+	lastAcquire AcquireDetails
+
+	slowLockThreshold time.Duration
+	slowLockAlert     func(name string, elapsed time.Duration)
+
+	acquiredCancels map[*context.CancelCauseFunc]struct{}
+
+	history *lockHistory
+	metrics mutexMetrics
+
+	extended          chan struct{}
+	extensionListener chan<- ExtensionEvent
+	extendAttempts    int64
+
+	publishEvents bool
+
+	capacity *capacityMonitor
+
+	releaseTimer *time.Timer
+
+	logger logger
+
+	safeRename bool
+
+	lockEnabled   func(ctx context.Context) bool
+	localFallback bool
+
 	pools []redis.Pool
+
+	// lockMu guards the state of the lock held by m, which is also read by the goroutines watching, extending or
+	// releasing the lock.
+	lockMu     sync.RWMutex
+	value      string
+	until      time.Time
+	acquiredAt time.Time
+	held       bool
+	soft       bool
+	persistent bool
+	bypassed   bool
+
+	mu sync.Mutex
 }
 
 // Name returns mutex name (i.e. the Redis key).
@@ -43,14 +112,114 @@ func (m *Mutex) Name() string {
 
 // Value returns the current random value. The value will be empty until a lock is acquired (or WithValue option is used).
 func (m *Mutex) Value() string {
+	m.lockMu.RLock()
+	defer m.lockMu.RUnlock()
 	return m.value
 }
 
+// SetExpiry changes the expiry of m. It is safe to call concurrently with other operations on m. The new expiry is
+// used by subsequent calls to Lock and Extend: it does not change the TTL of a lock already held in Redis until the
+// lock is next extended.
+func (m *Mutex) SetExpiry(d time.Duration) {
+	atomic.StoreInt64((*int64)(&m.expiry), int64(d))
+}
+
+// getExpiry returns the expiry of m, which may be changed concurrently by SetExpiry.
+func (m *Mutex) getExpiry() time.Duration {
+	return time.Duration(atomic.LoadInt64((*int64)(&m.expiry)))
+}
+
 // Until returns the time of validity of acquired lock. The value will be zero value until a lock is acquired.
 func (m *Mutex) Until() time.Time {
+	m.lockMu.RLock()
+	defer m.lockMu.RUnlock()
 	return m.until
 }
 
+// lockState returns the value and the time of validity of the lock held by m, and whether m holds it, read together.
+func (m *Mutex) lockState() (string, time.Time, bool) {
+	m.lockMu.RLock()
+	defer m.lockMu.RUnlock()
+	return m.value, m.until, m.held
+}
+
+// setLockState records the value, time of validity and time of acquisition of a newly acquired lock, and whether it
+// was acquired with SoftLock.
+func (m *Mutex) setLockState(value string, until, acquiredAt time.Time, soft bool) {
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+	m.value = value
+	m.until = until
+	m.acquiredAt = acquiredAt
+	m.held = true
+	m.soft = soft
+	m.persistent = false
+}
+
+// isSoft returns whether the lock held by m was acquired with SoftLock.
+func (m *Mutex) isSoft() bool {
+	m.lockMu.RLock()
+	defer m.lockMu.RUnlock()
+	return m.soft
+}
+
+// isPersistent returns whether the lock held by m was made persistent by LockForever.
+func (m *Mutex) isPersistent() bool {
+	m.lockMu.RLock()
+	defer m.lockMu.RUnlock()
+	return m.persistent
+}
+
+// setPersistent records that the lock held by m was made persistent by LockForever.
+func (m *Mutex) setPersistent() {
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+	m.persistent = true
+	m.until = forever
+}
+
+// isBypassed returns whether m holds a lock taken without Redis, as distributed locking was disabled.
+func (m *Mutex) isBypassed() bool {
+	m.lockMu.RLock()
+	defer m.lockMu.RUnlock()
+	return m.bypassed
+}
+
+// setBypassed records whether m holds a lock taken without Redis.
+func (m *Mutex) setBypassed(bypassed bool) {
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+	m.bypassed = bypassed
+}
+
+// heldValue returns the value of the lock held by m, and whether m holds a lock: the value is kept after the lock is
+// released, as it may have been set with WithValue.
+func (m *Mutex) heldValue() (string, bool) {
+	m.lockMu.RLock()
+	defer m.lockMu.RUnlock()
+	return m.value, m.held
+}
+
+// setReleased records that m no longer holds a lock.
+func (m *Mutex) setReleased() {
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+	m.held = false
+	m.persistent = false
+}
+
+// setUntil records the time of validity of the lock held by m after it was extended.
+func (m *Mutex) setUntil(until time.Time) {
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+	m.until = until
+}
+
+// Backoff returns the delay m waits before the given lock attempt, as computed by its retry delay function.
+func (m *Mutex) Backoff(attempt int) time.Duration {
+	return m.delayFunc(attempt)
+}
+
 // TryLock only attempts to lock m once and returns immediately regardless of success or failure without retrying.
 func (m *Mutex) TryLock() error {
 	return m.TryLockContext(context.Background())
@@ -58,7 +227,7 @@ func (m *Mutex) TryLock() error {
 
 // TryLockContext only attempts to lock m once and returns immediately regardless of success or failure without retrying.
 func (m *Mutex) TryLockContext(ctx context.Context) error {
-	return m.lockContext(ctx, 1)
+	return m.lockContext(ctx, 1, 0)
 }
 
 // Lock locks m. In case it returns an error on failure, you may retry to acquire the lock by calling this method again.
@@ -68,20 +237,215 @@ func (m *Mutex) Lock() error {
 
 // LockContext locks m. In case it returns an error on failure, you may retry to acquire the lock by calling this method again.
 func (m *Mutex) LockContext(ctx context.Context) error {
-	return m.lockContext(ctx, m.tries)
+	return m.lockContext(ctx, m.tries, 0)
+}
+
+// LockWithTimeout locks m like LockContext, but limits each attempt to acquire the lock, across all pools, to
+// perAttemptTimeout. Attempts that time out are retried; ctx still bounds the whole operation.
+func (m *Mutex) LockWithTimeout(ctx context.Context, perAttemptTimeout time.Duration) error {
+	return m.lockContext(ctx, m.tries, perAttemptTimeout)
+}
+
+// spinLockBudget is how long SpinLock spins before falling back to LockContext.
+const spinLockBudget = time.Millisecond
+
+// SpinLock locks m by retrying TryLock every spinInterval, instead of using the retry delay, for up to 1ms. It then
+// falls back to LockContext. SpinLock is CPU-intensive and loads Redis with requests; it should only be used for very
+// short-lived locks with an expected wait below 1ms.
+func (m *Mutex) SpinLock(ctx context.Context, spinInterval time.Duration) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	deadline := time.Now().Add(spinLockBudget)
+	for time.Now().Before(deadline) {
+		if err := m.TryLockContext(ctx); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ErrFailed
+		}
+		time.Sleep(spinInterval)
+	}
+	return m.LockContext(ctx)
+}
+
+// SampledLock locks m with probability sampleRate, for gradual rollouts of distributed locking. For the other calls it
+// returns (false, nil) without contacting Redis. It returns (true, nil) if the lock was acquired, in which case the
+// caller must call Unlock.
+func (m *Mutex) SampledLock(ctx context.Context, sampleRate float64) (bool, error) {
+	if mathrand.Float64() >= sampleRate {
+		return false, nil
+	}
+	if err := m.LockContext(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LockQuietly locks m like LockContext, but distinguishes contention from failures. It returns (true, nil) if the lock
+// was acquired, and (false, nil) if it was not because it is held by another client, the tries were exhausted or ctx
+// is done. It only returns an error on hard failures, such as network, authentication or script errors.
+func (m *Mutex) LockQuietly(ctx context.Context) (bool, error) {
+	err := m.LockContext(ctx)
+	if err == nil {
+		return true, nil
+	}
+	if isContention(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// isContention returns whether err, returned by an attempt to lock, only reports that the lock is held elsewhere or
+// could not be acquired in the allotted tries.
+func isContention(err error) bool {
+	var taken *ErrTaken
+	if errors.Is(err, ErrFailed) || errors.As(err, &taken) {
+		return true
+	}
+	var (
+		redisErr  *RedisError
+		nodeTaken *ErrNodeTaken
+	)
+	return !errors.As(err, &redisErr) && errors.As(err, &nodeTaken)
+}
+
+// LockWithExpectedTTL locks m and ensures the remaining validity of the lock, after the time spent acquiring it and the
+// clock drift are subtracted, is at least expectedTTL. If it is not, the lock is released and acquired again, up to the
+// configured number of tries. If no attempt achieved expectedTTL, it returns an *ErrInsufficientTTL holding the
+// longest TTL achieved.
+func (m *Mutex) LockWithExpectedTTL(ctx context.Context, expectedTTL time.Duration) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var (
+		timer   *time.Timer
+		lastErr error
+		best    *ErrInsufficientTTL
+	)
+	for i := 0; i < m.tries; i++ {
+		if i != 0 {
+			if timer == nil {
+				timer = time.NewTimer(m.delayFunc(i))
+			} else {
+				timer.Reset(m.delayFunc(i))
+			}
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				// Exit early if the context is done.
+				return ErrFailed
+			case <-timer.C:
+				// Fall-through when the delay timer completes.
+			}
+		}
+
+		if err := m.lockContext(ctx, 1, 0); err != nil {
+			lastErr = err
+			continue
+		}
+		ttl := time.Until(m.Until())
+		if ttl >= expectedTTL {
+			return nil
+		}
+		_, _ = m.UnlockContext(ctx)
+		if best == nil || ttl > best.TTL {
+			best = &ErrInsufficientTTL{Expected: expectedTTL, TTL: ttl}
+		}
+	}
+	if best != nil {
+		return best
+	}
+	if lastErr == nil {
+		lastErr = ErrFailed
+	}
+	return lastErr
 }
 
 // lockContext locks m. In case it returns an error on failure, you may retry to acquire the lock by calling this method again.
-func (m *Mutex) lockContext(ctx context.Context, tries int) error {
+func (m *Mutex) lockContext(ctx context.Context, tries int, attemptTimeout time.Duration) (err error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	value, err := m.genValueFunc()
+	if m.reentrantKey != nil {
+		if value, until, held := m.lockState(); held && value != "" && ctx.Value(m.reentrantKey) == value && time.Now().Before(until) {
+			// The context carries the token of the lock m holds.
+			return nil
+		}
+	}
+
+	prevState, err := m.beginTransition(StateLocking, StateCreated, StateUnlocked)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			m.endTransition(prevState)
+		} else {
+			m.endTransition(StateLocked)
+		}
+		m.metrics.recordLock(time.Since(start), err)
+		m.observeLock(err)
+	}()
+
+	if m.bypassLock(ctx) {
+		if err := m.lockLocally(ctx, tries); err != nil {
+			return err
+		}
+		m.stopReleaseTimer()
+		return nil
+	}
+
+	value, err := m.newValue(ctx)
 	if err != nil {
 		return err
 	}
 
+	until, fastest, err := m.lockWithRetries(ctx, tries, attemptTimeout, value)
+	if err != nil {
+		return err
+	}
+	if m.histogram != nil {
+		m.histogram.observe(time.Since(start))
+	}
+	m.setLockState(value, until, time.Now(), false)
+	m.stopReleaseTimer()
+	atomic.StoreInt64(&m.extendAttempts, 0)
+	if m.stickyPool {
+		m.stickyNode = fastest
+	}
+	m.register(ctx)
+	m.writeMetadata(ctx)
+	m.publishEvent(ctx, EventLock)
+	return nil
+}
+
+// lockWithRetries attempts to acquire the lock with the given value up to tries times, each attempt being limited to
+// attemptTimeout if it is not zero. On success, it returns the time of validity of the lock and the node that was
+// first to grant it. It does not modify m, so that it can be used concurrently.
+func (m *Mutex) lockWithRetries(ctx context.Context, tries int, attemptTimeout time.Duration, value string) (_ time.Time, _ int, err error) {
+	attempts := 0
+	defer func() {
+		m.history.record(EventLock, attempts, err)
+		m.logLock(value, attempts, err)
+	}()
+
+	began := time.Now()
+	alerted := m.slowLockAlert == nil
+	checkSlow := func() {
+		if alerted {
+			return
+		}
+		if elapsed := time.Since(began); elapsed > m.slowLockThreshold {
+			alerted = true
+			m.slowLockAlert(m.name, elapsed)
+		}
+	}
+
 	var timer *time.Timer
 	for i := 0; i < tries; i++ {
 		if i != 0 {
@@ -95,42 +459,233 @@ func (m *Mutex) lockContext(ctx context.Context, tries int) error {
 			case <-ctx.Done():
 				timer.Stop()
 				// Exit early if the context is done.
-				return ErrFailed
+				return time.Time{}, -1, ErrFailed
 			case <-timer.C:
 				// Fall-through when the delay timer completes.
 			}
+			checkSlow()
 		}
 
+		if m.acquireLimiter != nil {
+			if err := m.acquireLimiter.Wait(ctx); err != nil {
+				// The context is done, or would be before a token is available.
+				return time.Time{}, -1, ErrFailed
+			}
+		}
+
+		m.checkCapacity(ctx)
+
+		attempts++
 		start := time.Now()
 
-		n, err := func() (int, error) {
-			ctx, cancel := context.WithTimeout(ctx, time.Duration(int64(float64(m.expiry)*m.timeoutFactor)))
+		var (
+			mu            sync.Mutex
+			serverElapsed time.Duration
+			timings       = newAcquireTimings(start, len(m.pools))
+		)
+		n, fastest, err := func() (int, int, error) {
+			attemptCtx := ctx
+			if attemptTimeout > 0 {
+				var cancel context.CancelFunc
+				attemptCtx, cancel = context.WithTimeout(ctx, attemptTimeout)
+				defer cancel()
+			}
+			timeout := time.Duration(int64(float64(m.getExpiry()) * m.timeoutFactor))
+			ctx, cancel := context.WithTimeout(attemptCtx, timeout)
 			defer cancel()
-			return m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
-				return m.acquire(ctx, pool, value)
+			first := -1
+			if m.stickyPool {
+				first = m.stickyNode
+			}
+			return m.actOnPoolsAsyncFrom(first, timeout/stickyHeadStartDivisor, func(node int, pool redis.Pool) (bool, error) {
+				if !m.serverSideTime {
+					ok, err := m.acquire(ctx, pool, value)
+					timings.record(node, ok, err)
+					return ok, err
+				}
+				ok, elapsed, err := m.acquireServerTimed(ctx, pool, value)
+				timings.record(node, ok, err)
+				if ok {
+					mu.Lock()
+					if elapsed > serverElapsed {
+						serverElapsed = elapsed
+					}
+					mu.Unlock()
+				}
+				return ok, err
 			})
 		}()
 
 		now := time.Now()
-		until := now.Add(m.expiry - now.Sub(start) - time.Duration(int64(float64(m.expiry)*m.driftFactor)))
+		elapsed := now.Sub(start)
+		if m.serverSideTime {
+			mu.Lock()
+			elapsed = serverElapsed
+			mu.Unlock()
+		}
+		drift := time.Duration(int64(float64(m.getExpiry()) * m.driftFactor))
+		until := now.Add(m.getExpiry() - elapsed - drift)
+		m.setAcquireDetails(timings.details(attempts, m.quorum, drift, until))
+		checkSlow()
 		if n >= m.quorum && now.Before(until) {
-			m.value = value
-			m.until = until
-			return nil
+			return until, fastest, nil
+		}
+		if m.stickyPool {
+			// The preferred pool may be unhealthy: dispatch to all pools at once on the next attempt.
+			m.stickyNode = -1
 		}
 		_, _ = func() (int, error) {
-			ctx, cancel := context.WithTimeout(ctx, time.Duration(int64(float64(m.expiry)*m.timeoutFactor)))
+			ctx, cancel := context.WithTimeout(ctx, time.Duration(int64(float64(m.getExpiry())*m.timeoutFactor)))
 			defer cancel()
 			return m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
 				return m.release(ctx, pool, value)
 			})
 		}()
 		if i == tries-1 && err != nil {
-			return err
+			return time.Time{}, -1, err
+		}
+		if i != tries-1 {
+			m.history.record(EventRetry, attempts, err)
+			m.logRetry(attempts, err)
+		}
+	}
+
+	return time.Time{}, -1, ErrFailed
+}
+
+// SoftLock makes a single attempt to set the lock on all pools and succeeds if at least one pool accepted it. It
+// returns (true, nil) if the lock was acquired, in which case Unlock and Extend also only require one pool to succeed.
+//
+// SoftLock provides none of the safety guarantees of Redlock: several clients may hold the lock at the same time. It is
+// only meant for advisory locks and best-effort serialization, where correctness can be verified after the fact.
+func (m *Mutex) SoftLock(ctx context.Context) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	prevState, err := m.beginTransition(StateLocking, StateCreated, StateUnlocked)
+	if err != nil {
+		return false, err
+	}
+
+	value, err := m.newValue(ctx)
+	if err != nil {
+		m.endTransition(prevState)
+		m.observeLock(err)
+		return false, err
+	}
+
+	start := time.Now()
+	n, err := func() (int, error) {
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(int64(float64(m.getExpiry())*m.timeoutFactor)))
+		defer cancel()
+		return m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+			return m.acquire(ctx, pool, value)
+		})
+	}()
+	now := time.Now()
+	until := now.Add(m.getExpiry() - now.Sub(start) - time.Duration(int64(float64(m.getExpiry())*m.driftFactor)))
+	if n == 0 || !now.Before(until) {
+		m.endTransition(prevState)
+		if err == nil {
+			err = ErrFailed
 		}
+		m.observeLock(err)
+		return false, err
+	}
+
+	m.setLockState(value, until, now, true)
+	m.stopReleaseTimer()
+	atomic.StoreInt64(&m.extendAttempts, 0)
+	m.endTransition(StateLocked)
+	m.register(ctx)
+	m.writeMetadata(ctx)
+	m.publishEvent(ctx, EventLock)
+	m.observeLock(nil)
+	return true, nil
+}
+
+// heldQuorum returns the number of pools that must succeed for operations on the lock held by m.
+func (m *Mutex) heldQuorum() int {
+	if m.isSoft() {
+		return 1
 	}
+	return m.quorum
+}
 
-	return ErrFailed
+// LockWithEphemeralValue acquires the lock with a newly generated value and returns that value without storing it in
+// m. The lock must be released with UnlockWithValue. Unlike Lock, it is safe to call concurrently on a shared mutex.
+func (m *Mutex) LockWithEphemeralValue(ctx context.Context) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	value, err := m.newValue(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := m.lockWithRetries(ctx, m.tries, 0, value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// UnlockWithValue releases the lock acquired with the given value, as returned by LockWithEphemeralValue, and returns
+// the status of unlock.
+func (m *Mutex) UnlockWithValue(ctx context.Context, value string) (bool, error) {
+	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+		return m.release(ctx, pool, value)
+	})
+	if n < m.quorum {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddLockContext returns a copy of ctx carrying the token of the lock held by m, for use with the
+// WithReentrantContext option. Locking m again with the returned context, or a context derived from it, succeeds
+// immediately while the lock is held. It returns ctx unchanged if m does not use WithReentrantContext or does not hold a
+// lock.
+func AddLockContext(ctx context.Context, m *Mutex) context.Context {
+	value, held := m.heldValue()
+	if m.reentrantKey == nil || !held || value == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, m.reentrantKey, value)
+}
+
+// ReleaseAfter unlocks m after d has elapsed, in the background, and returns immediately. It returns ErrLockNotHeld if
+// m does not hold a lock. If m is unlocked or locked again before d elapses, the delayed unlock is cancelled: it only
+// ever releases the lock held when ReleaseAfter was called. The values of ctx are passed to the delayed unlock, but its
+// cancellation is not: the lock is released even if ctx is done by then.
+func (m *Mutex) ReleaseAfter(ctx context.Context, d time.Duration) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	value, held := m.heldValue()
+	if !held && !m.isBypassed() {
+		return ErrLockNotHeld
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.releaseTimer != nil {
+		m.releaseTimer.Stop()
+	}
+	m.releaseTimer = time.AfterFunc(d, func() {
+		_, _ = m.unlock(ctx, value, m.release)
+	})
+	return nil
+}
+
+// stopReleaseTimer cancels the delayed unlock started by ReleaseAfter, if any.
+func (m *Mutex) stopReleaseTimer() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.releaseTimer != nil {
+		m.releaseTimer.Stop()
+		m.releaseTimer = nil
+	}
 }
 
 // Unlock unlocks m and returns the status of unlock.
@@ -140,15 +695,101 @@ func (m *Mutex) Unlock() (bool, error) {
 
 // UnlockContext unlocks m and returns the status of unlock.
 func (m *Mutex) UnlockContext(ctx context.Context) (bool, error) {
+	return m.unlock(ctx, m.Value(), m.release)
+}
+
+// unlock releases the lock held by m with the given value on each pool with release. It returns ErrLockNotHeld if m
+// has since acquired a lock with another value.
+func (m *Mutex) unlock(ctx context.Context, value string, release func(ctx context.Context, pool redis.Pool, value string) (bool, error)) (bool, error) {
+	prevState, err := m.beginTransition(StateUnlocking, StateLocked)
+	if err != nil {
+		return false, err
+	}
+	if !m.isBypassed() && value != m.Value() {
+		m.endTransition(prevState)
+		return false, ErrLockNotHeld
+	}
+	m.stopReleaseTimer()
+	if m.isBypassed() {
+		m.unlockLocally()
+		m.endTransition(StateUnlocked)
+		m.metrics.recordUnlock()
+		return true, nil
+	}
+	m.deleteMetadata(ctx)
+
 	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
-		return m.release(ctx, pool, m.value)
+		return release(ctx, pool, value)
 	})
-	if n < m.quorum {
+	if n < m.heldQuorum() {
+		if errors.Is(err, ErrLockAlreadyExpired) {
+			m.history.record(EventExpire, 0, err)
+			m.setReleased()
+			m.endTransition(StateUnlocked)
+			m.cancelAcquiredContexts(ErrLockLost)
+		} else {
+			m.endTransition(StateLocked)
+		}
+		m.history.record(EventUnlock, 0, err)
+		m.logFailure("unlock", err)
 		return false, err
 	}
+	m.setReleased()
+	m.history.record(EventUnlock, 0, nil)
+	m.metrics.recordUnlock()
+	m.logRelease()
+	m.endTransition(StateUnlocked)
+	m.unregister(ctx)
+	m.publishEvent(ctx, EventUnlock)
+	m.cancelAcquiredContexts(context.Canceled)
+	if m.observer != nil {
+		m.observer.LockReleased(m.name)
+	}
 	return true, nil
 }
 
+var deleteAndPublishScript = redis.NewScript(1, `
+	local val = redis.call("GET", KEYS[1])
+	if val == ARGV[1] then
+		redis.call("DEL", KEYS[1])
+		redis.call("PUBLISH", ARGV[2], ARGV[3])
+		return 1
+	elseif val == false then
+		return -1
+	else
+		return 0
+	end
+`)
+
+// UnlockAndPublish unlocks m and, in the same atomic operation, publishes message to the Redis Pub/Sub channel, so
+// that the next holder is notified without a gap between the release and the notification. The message is published
+// on each pool the lock is released on: subscribers connected to several pools receive it several times. Like
+// UnlockContext, it fails if the lock could not be released on a quorum of pools.
+func (m *Mutex) UnlockAndPublish(ctx context.Context, channel, message string) error {
+	ok, err := m.unlock(ctx, m.Value(), func(ctx context.Context, pool redis.Pool, value string) (bool, error) {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer conn.Close()
+		status, err := conn.Eval(deleteAndPublishScript, m.name, value, channel, message)
+		if err != nil {
+			return false, err
+		}
+		if status == int64(-1) {
+			return false, ErrLockAlreadyExpired
+		}
+		return status != int64(0), nil
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
 // Extend resets the mutex's expiry and returns the status of expiry extension.
 func (m *Mutex) Extend() (bool, error) {
 	return m.ExtendContext(context.Background())
@@ -156,22 +797,172 @@ func (m *Mutex) Extend() (bool, error) {
 
 // ExtendContext resets the mutex's expiry and returns the status of expiry extension.
 func (m *Mutex) ExtendContext(ctx context.Context) (bool, error) {
+	ok, err := m.extendContext(ctx)
+	m.notifyExtensionListener(ok, err)
+	return ok, err
+}
+
+func (m *Mutex) extendContext(ctx context.Context) (bool, error) {
+	if _, err := m.beginTransition(StateLocked, StateLocked); err != nil {
+		return false, err
+	}
+	if m.isBypassed() {
+		m.setUntil(time.Now().Add(m.getExpiry()))
+		m.metrics.recordExtend(true)
+		return true, nil
+	}
+	if m.isPersistent() {
+		m.metrics.recordExtend(true)
+		return true, nil
+	}
+
+	expiry := m.getExpiry()
 	start := time.Now()
 	n, err := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
-		return m.touch(ctx, pool, m.value, int(m.expiry/time.Millisecond))
+		return m.touch(ctx, pool, m.Value(), int(expiry/time.Millisecond))
 	})
-	if n < m.quorum {
+	if n < m.heldQuorum() {
+		m.history.record(EventExtend, 0, err)
+		m.metrics.recordExtend(false)
+		m.logFailure("extend", err)
 		return false, err
 	}
 	now := time.Now()
-	until := now.Add(m.expiry - now.Sub(start) - time.Duration(int64(float64(m.expiry)*m.driftFactor)))
+	until := now.Add(expiry - now.Sub(start) - time.Duration(int64(float64(expiry)*m.driftFactor)))
 	if now.Before(until) {
-		m.until = until
+		m.setUntil(until)
+		m.register(ctx)
+		m.touchMetadata(ctx)
+		m.publishEvent(ctx, EventExtend)
+		m.history.record(EventExtend, 0, nil)
+		m.metrics.recordExtend(true)
+		m.notifyExtended()
 		return true, nil
 	}
+	m.history.record(EventExtend, 0, ErrExtendFailed)
+	m.metrics.recordExtend(false)
+	m.logFailure("extend", ErrExtendFailed)
 	return false, ErrExtendFailed
 }
 
+// WaitForExtend waits until the lock held by m is next successfully extended, for instance by a renewal goroutine. It
+// returns (true, nil) once the lock has been extended and (false, nil) if maxWait elapses first. If ctx is done first,
+// it returns false and the context's error.
+func (m *Mutex) WaitForExtend(ctx context.Context, maxWait time.Duration) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	m.mu.Lock()
+	if m.extended == nil {
+		m.extended = make(chan struct{})
+	}
+	extended := m.extended
+	m.mu.Unlock()
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	select {
+	case <-extended:
+		return true, nil
+	case <-timer.C:
+		return false, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// notifyExtended wakes up the callers of WaitForExtend.
+func (m *Mutex) notifyExtended() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.extended != nil {
+		close(m.extended)
+		m.extended = nil
+	}
+}
+
+// IncrementalExtend extends the lock like ExtendContext, but only if its remaining TTL is below threshold, to save
+// the extension of a lock that was recently acquired or extended. The TTL is read from the pools first: the lock is
+// extended if the smallest TTL across a quorum of the pools holding it is below threshold, or if it is not held on
+// a quorum of pools. It returns (false, nil) if the extension was skipped.
+func (m *Mutex) IncrementalExtend(ctx context.Context, threshold time.Duration) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if m.isPersistent() {
+		return false, nil
+	}
+	statuses, _ := m.InspectPools(ctx)
+	var ttls []time.Duration
+	for _, status := range statuses {
+		if status.ValueMatches && status.TTL > 0 {
+			ttls = append(ttls, status.TTL)
+		}
+	}
+	quorum := m.heldQuorum()
+	if len(ttls) >= quorum {
+		sort.Slice(ttls, func(i, j int) bool {
+			return ttls[i] > ttls[j]
+		})
+		if ttls[quorum-1] >= threshold {
+			return false, nil
+		}
+	}
+	return m.ExtendContext(ctx)
+}
+
+// ExtendWithCallback resets the mutex's expiry and reports the outcome through the given callbacks instead of
+// return values. On success, onSuccess is called with the remaining validity of the lock. On failure, onFailure is
+// called with the reason. Either callback may be nil. It is meant to be run as `go m.ExtendWithCallback(...)` from
+// renewal loops that only need to log or react to failures.
+func (m *Mutex) ExtendWithCallback(ctx context.Context, onSuccess func(remaining time.Duration), onFailure func(err error)) {
+	ok, err := m.ExtendContext(ctx)
+	if ok {
+		if onSuccess != nil {
+			onSuccess(time.Until(m.Until()))
+		}
+		return
+	}
+	if err == nil {
+		err = ErrExtendFailed
+	}
+	if onFailure != nil {
+		onFailure(err)
+	}
+}
+
+// maxConcurrentExtends limits the number of mutexes TryExtendAll extends at the same time.
+const maxConcurrentExtends = 16
+
+// TryExtendAll concurrently resets the expiry of all the given mutexes. It returns a slice of errors parallel to
+// mutexes, where a nil error indicates that the corresponding mutex was extended.
+func TryExtendAll(ctx context.Context, mutexes ...*Mutex) []error {
+	errs := make([]error, len(mutexes))
+	sem := semaphore.NewWeighted(maxConcurrentExtends)
+
+	var wg sync.WaitGroup
+	for i, m := range mutexes {
+		if err := sem.Acquire(ctx, 1); err != nil {
+			for j := i; j < len(mutexes); j++ {
+				errs[j] = err
+			}
+			break
+		}
+		wg.Add(1)
+		go func(i int, m *Mutex) {
+			defer wg.Done()
+			defer sem.Release(1)
+			ok, err := m.ExtendContext(ctx)
+			if !ok && err == nil {
+				err = ErrExtendFailed
+			}
+			errs[i] = err
+		}(i, m)
+	}
+	wg.Wait()
+	return errs
+}
+
 // Valid returns true if the lock acquired through m is still valid. It may
 // also return true erroneously if quorum is achieved during the call and at
 // least one node then takes long enough to respond for the lock to expire.
@@ -194,7 +985,7 @@ func (m *Mutex) ValidContext(ctx context.Context) (bool, error) {
 }
 
 func (m *Mutex) valid(ctx context.Context, pool redis.Pool) (bool, error) {
-	if m.value == "" {
+	if m.Value() == "" {
 		return false, nil
 	}
 	conn, err := pool.Get(ctx)
@@ -206,7 +997,7 @@ func (m *Mutex) valid(ctx context.Context, pool redis.Pool) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return m.value == reply, nil
+	return m.Value() == reply, nil
 }
 
 func genValue() (string, error) {
@@ -218,17 +1009,111 @@ func genValue() (string, error) {
 	return base64.StdEncoding.EncodeToString(b), nil
 }
 
+// newValue generates the value for a new lock, suffixed with the next version if versioned locks are enabled.
+func (m *Mutex) newValue(ctx context.Context) (string, error) {
+	value, err := m.genValueFunc()
+	if err != nil {
+		return "", err
+	}
+	if !m.versionedLock {
+		return value, nil
+	}
+	version, err := m.nextVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return value + ":v" + strconv.FormatInt(version, 10), nil
+}
+
+var incrScript = redis.NewScript(1, `
+	return redis.call("INCR", KEYS[1])
+`)
+
+// nextVersion increments the version counter of m on all pools and returns the highest version among them. It fails
+// unless at least quorum pools were incremented.
+func (m *Mutex) nextVersion(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(int64(float64(m.getExpiry())*m.timeoutFactor)))
+	defer cancel()
+
+	type result struct {
+		node    int
+		version int64
+		err     error
+	}
+
+	ch := make(chan result, len(m.pools))
+	for node, pool := range m.pools {
+		go func(node int, pool redis.Pool) {
+			r := result{node: node}
+			conn, err := pool.Get(ctx)
+			if err != nil {
+				r.err = err
+				ch <- r
+				return
+			}
+			defer conn.Close()
+			reply, err := conn.Eval(incrScript, m.name+":version")
+			r.version, r.err = replyInt64(reply), err
+			ch <- r
+		}(node, pool)
+	}
+
+	var (
+		n       = 0
+		version int64
+		err     error
+	)
+	for range m.pools {
+		r := <-ch
+		if r.err != nil {
+			err = multierror.Append(err, &RedisError{Node: r.node, Err: r.err})
+			continue
+		}
+		n++
+		if r.version > version {
+			version = r.version
+		}
+	}
+	if n < m.quorum {
+		return 0, err
+	}
+	return version, nil
+}
+
 func (m *Mutex) acquire(ctx context.Context, pool redis.Pool, value string) (bool, error) {
 	conn, err := pool.Get(ctx)
 	if err != nil {
 		return false, err
 	}
 	defer conn.Close()
-	reply, err := conn.SetNX(m.name, value, m.expiry)
+	return m.setNX(ctx, conn, value)
+}
+
+var timeScript = redis.NewScript(0, `
+	local t = redis.call("TIME")
+	return t[1] * 1000000 + t[2]
+`)
+
+// acquireServerTimed is like acquire, but also returns the time it took as measured by the Redis server's clock.
+func (m *Mutex) acquireServerTimed(ctx context.Context, pool redis.Pool, value string) (bool, time.Duration, error) {
+	conn, err := pool.Get(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	defer conn.Close()
+	before, err := conn.Eval(timeScript)
 	if err != nil {
-		return false, err
+		return false, 0, err
+	}
+	reply, err := m.setNX(ctx, conn, value)
+	if err != nil {
+		return false, 0, err
 	}
-	return reply, nil
+	after, err := conn.Eval(timeScript)
+	if err != nil {
+		return false, 0, err
+	}
+	return reply, time.Duration(replyInt64(after)-replyInt64(before)) * time.Microsecond, nil
 }
 
 var deleteScript = redis.NewScript(1, `
@@ -296,6 +1181,20 @@ func (m *Mutex) touch(ctx context.Context, pool redis.Pool, value string, expiry
 }
 
 func (m *Mutex) actOnPoolsAsync(actFn func(redis.Pool) (bool, error)) (int, error) {
+	n, _, err := m.actOnPoolsAsyncFrom(-1, 0, func(node int, pool redis.Pool) (bool, error) {
+		return actFn(pool)
+	})
+	return n, err
+}
+
+// stickyHeadStartDivisor is the fraction of the timeout of a lock attempt, as its divisor, during which the pool
+// preferred by WithStickyPool is acted on alone.
+const stickyHeadStartDivisor = 4
+
+// actOnPoolsAsyncFrom is like actOnPoolsAsync, but if first is a node, acts on its pool first and only acts on the
+// others concurrently once it has replied or headStart has elapsed. It additionally returns the node that was first to
+// report success, or -1 if none did. actFn is also given the node of the pool.
+func (m *Mutex) actOnPoolsAsyncFrom(first int, headStart time.Duration, actFn func(node int, pool redis.Pool) (bool, error)) (int, int, error) {
 	type result struct {
 		node     int
 		statusOK bool
@@ -303,23 +1202,45 @@ func (m *Mutex) actOnPoolsAsync(actFn func(redis.Pool) (bool, error)) (int, erro
 	}
 
 	ch := make(chan result, len(m.pools))
-	for node, pool := range m.pools {
+	if first >= 0 && first < len(m.pools) {
+		replied := make(chan struct{})
+		go func() {
+			r := result{node: first}
+			r.statusOK, r.err = actFn(first, m.pools[first])
+			ch <- r
+			close(replied)
+		}()
+		timer := time.NewTimer(headStart)
+		select {
+		case <-replied:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+	for node := range m.pools {
+		if node == first {
+			continue
+		}
 		go func(node int, pool redis.Pool) {
 			r := result{node: node}
-			r.statusOK, r.err = actFn(pool)
+			r.statusOK, r.err = actFn(node, pool)
 			ch <- r
-		}(node, pool)
+		}(node, m.pools[node])
 	}
 
 	var (
-		n     = 0
-		taken []int
-		err   error
+		n       = 0
+		fastest = -1
+		taken   []int
+		err     error
 	)
 
 	for range m.pools {
 		r := <-ch
 		if r.statusOK {
+			if fastest == -1 {
+				fastest = r.node
+			}
 			n++
 		} else if r.err == ErrLockAlreadyExpired {
 			err = multierror.Append(err, ErrLockAlreadyExpired)
@@ -333,18 +1254,18 @@ func (m *Mutex) actOnPoolsAsync(actFn func(redis.Pool) (bool, error)) (int, erro
 		if m.failFast {
 			// fast return
 			if n >= m.quorum {
-				return n, err
+				return n, fastest, err
 			}
 
 			// fail fast
 			if len(taken) >= m.quorum {
-				return n, &ErrTaken{Nodes: taken}
+				return n, fastest, &ErrTaken{Nodes: taken}
 			}
 		}
 	}
 
 	if len(taken) >= m.quorum {
-		return n, &ErrTaken{Nodes: taken}
+		return n, fastest, &ErrTaken{Nodes: taken}
 	}
-	return n, err
+	return n, fastest, err
 }
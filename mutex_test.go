@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -139,6 +143,41 @@ func TestMutexExtend(t *testing.T) {
 	}
 }
 
+func TestMutexSetExpiry(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			mutexes := newTestMutexes(v.pools, k+"-test-mutex-set-expiry", 1)
+			mutex := mutexes[0]
+
+			err := mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			defer mutex.Unlock()
+
+			mutex.SetExpiry(30 * time.Second)
+			for i, expiry := range getPoolExpiries(v.pools, mutex.name) {
+				if expiry > int(8*time.Second) {
+					t.Fatalf("Expected TTL of pool %d to be unchanged before extend, got %d", i, expiry)
+				}
+			}
+
+			ok, err := mutex.Extend()
+			if err != nil || !ok {
+				t.Fatalf("mutex extend failed: %v", err)
+			}
+			for i, expiry := range getPoolExpiries(v.pools, mutex.name) {
+				if expiry <= int(8*time.Second) {
+					t.Fatalf("Expected TTL of pool %d to exceed 8s after extend, got %d", i, expiry)
+				}
+			}
+			if remaining := time.Until(mutex.Until()); remaining <= 8*time.Second {
+				t.Fatalf("Expected validity to exceed 8s after extend, got %s", remaining)
+			}
+		})
+	}
+}
+
 func TestMutexExtendExpired(t *testing.T) {
 	for k, v := range makeCases(8) {
 		t.Run(k, func(t *testing.T) {
@@ -162,6 +201,71 @@ func TestMutexExtendExpired(t *testing.T) {
 	}
 }
 
+func TestMutexExtendWithCallback(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			mutexes := newTestMutexes(v.pools, k+"-test-mutex-extend-callback", 1)
+			mutex := mutexes[0]
+			mutex.expiry = 500 * time.Millisecond
+
+			err := mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+
+			var remaining time.Duration
+			mutex.ExtendWithCallback(context.Background(), func(d time.Duration) {
+				remaining = d
+			}, func(err error) {
+				t.Fatalf("mutex extend failed: %s", err)
+			})
+			if remaining <= 0 || remaining > mutex.expiry {
+				t.Fatalf("Expected 0 < remaining <= %s, got %s", mutex.expiry, remaining)
+			}
+
+			time.Sleep(1 * time.Second)
+
+			var failure error
+			mutex.ExtendWithCallback(context.Background(), func(d time.Duration) {
+				t.Fatalf("mutex extend didn't fail")
+			}, func(err error) {
+				failure = err
+			})
+			if failure == nil {
+				t.Fatalf("Expected onFailure to be called with an error")
+			}
+		})
+	}
+}
+
+func TestTryExtendAll(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			mutexes := newTestMutexes(v.pools, k+"-test-try-extend-all", 3)
+			for _, mutex := range mutexes[:2] {
+				err := mutex.Lock()
+				if err != nil {
+					t.Fatalf("mutex lock failed: %s", err)
+				}
+				defer mutex.Unlock()
+			}
+
+			errs := TryExtendAll(context.Background(), mutexes...)
+			if len(errs) != len(mutexes) {
+				t.Fatalf("Expected %d errors, got %d", len(mutexes), len(errs))
+			}
+			for i, err := range errs[:2] {
+				if err != nil {
+					t.Fatalf("mutex %d extend failed: %s", i, err)
+				}
+			}
+			if errs[2] == nil {
+				t.Fatalf("Expected extending an unlocked mutex to fail")
+			}
+		})
+	}
+}
+
 func TestSetNXOnExtendAcquiresLockWhenKeyIsExpired(t *testing.T) {
 	for k, v := range makeCases(8) {
 		t.Run(k, func(t *testing.T) {
@@ -331,6 +435,760 @@ func TestMutexLockUnlockSplit(t *testing.T) {
 	}
 }
 
+func TestMutexStateMachine(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-state-machine", WithStateMachine(true))
+
+			assertInvalidTransition := func(err error) {
+				t.Helper()
+				var errInvalid *ErrInvalidTransition
+				if !errors.As(err, &errInvalid) {
+					t.Fatalf("Expected ErrInvalidTransition, got %v", err)
+				}
+			}
+
+			if state := mutex.State(); state != StateCreated {
+				t.Fatalf("Expected state %s, got %s", StateCreated, state)
+			}
+			_, err := mutex.Unlock()
+			assertInvalidTransition(err)
+			_, err = mutex.Extend()
+			assertInvalidTransition(err)
+
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			if state := mutex.State(); state != StateLocked {
+				t.Fatalf("Expected state %s, got %s", StateLocked, state)
+			}
+			assertInvalidTransition(mutex.Lock())
+
+			ok, err := mutex.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+			if state := mutex.State(); state != StateUnlocked {
+				t.Fatalf("Expected state %s, got %s", StateUnlocked, state)
+			}
+			_, err = mutex.Unlock()
+			assertInvalidTransition(err)
+
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex relock failed: %s", err)
+			}
+			_, _ = mutex.Unlock()
+		})
+	}
+}
+
+func TestMutexInspectPools(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			mutexes := newTestMutexes(v.pools, k+"-test-inspect-pools", 1)
+			mutex := mutexes[0]
+
+			statuses, err := mutex.InspectPools(ctx)
+			if err != nil {
+				t.Fatalf("mutex inspect pools failed: %s", err)
+			}
+			for _, status := range statuses {
+				if !status.Reachable || status.KeyExists || status.ValueMatches {
+					t.Fatalf("Expected reachable pool without key, got %+v", status)
+				}
+			}
+
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			defer mutex.Unlock()
+
+			statuses, err = mutex.InspectPools(ctx)
+			if err != nil {
+				t.Fatalf("mutex inspect pools failed: %s", err)
+			}
+			if len(statuses) != len(v.pools) {
+				t.Fatalf("Expected %d statuses, got %d", len(v.pools), len(statuses))
+			}
+			for i, status := range statuses {
+				if status.Index != i {
+					t.Fatalf("Expected index %d, got %d", i, status.Index)
+				}
+				if !status.Reachable || !status.KeyExists || !status.ValueMatches || status.Value != mutex.Value() {
+					t.Fatalf("Expected pool to hold the lock, got %+v", status)
+				}
+				if status.TTL <= 0 || status.TTL > mutex.expiry {
+					t.Fatalf("Expected 0 < TTL <= %s, got %s", mutex.expiry, status.TTL)
+				}
+			}
+		})
+	}
+}
+
+func TestMutexKeyEncoding(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			mutexes := newTestMutexes(v.pools, k+"-test-key-encoding", 1)
+			mutex := mutexes[0]
+
+			encoding, err := mutex.KeyEncoding(ctx)
+			if err != nil {
+				t.Fatalf("mutex key encoding failed: %s", err)
+			}
+			if encoding != "" {
+				t.Fatalf("Expected no encoding for a missing key, got %q", encoding)
+			}
+
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			defer mutex.Unlock()
+
+			encoding, err = mutex.KeyEncoding(ctx)
+			if err != nil {
+				t.Fatalf("mutex key encoding failed: %s", err)
+			}
+			if encoding != "embstr" {
+				t.Fatalf("Expected embstr encoding, got %q", encoding)
+			}
+		})
+	}
+}
+
+func TestMutexSnapshot(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			mutexes := newTestMutexes(v.pools, k+"-test-snapshot", 1)
+			mutex := mutexes[0]
+
+			snapshot, err := mutex.Snapshot()
+			if err != nil {
+				t.Fatalf("mutex snapshot failed: %s", err)
+			}
+			if snapshot.HeldByUs || snapshot.TTL != 0 || len(snapshot.Pools) != len(v.pools) {
+				t.Fatalf("Expected snapshot of an unlocked mutex, got %+v", snapshot)
+			}
+
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			defer mutex.Unlock()
+
+			snapshot, err = mutex.Snapshot()
+			if err != nil {
+				t.Fatalf("mutex snapshot failed: %s", err)
+			}
+			if snapshot.Name != mutex.Name() || snapshot.Value != mutex.Value() || !snapshot.HeldByUs {
+				t.Fatalf("Expected snapshot of a held lock, got %+v", snapshot)
+			}
+			if snapshot.TTL <= 0 || snapshot.TTL > mutex.expiry {
+				t.Fatalf("Expected 0 < TTL <= %s, got %s", mutex.expiry, snapshot.TTL)
+			}
+		})
+	}
+}
+
+func TestMutexAcquireRateLimit(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-acquire-rate-limit", WithAcquireRateLimit(10))
+
+			start := time.Now()
+			for i := 0; i < 3; i++ {
+				err := mutex.Lock()
+				if err != nil {
+					t.Fatalf("mutex lock failed: %s", err)
+				}
+				ok, err := mutex.Unlock()
+				if err != nil || !ok {
+					t.Fatalf("mutex unlock failed: %v", err)
+				}
+			}
+			if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+				t.Fatalf("Expected 3 acquisitions at 10 rps to take at least 200ms, took %s", elapsed)
+			}
+
+			// The limit is shared by the mutexes created with the same option.
+			limit := WithAcquireRateLimit(10)
+			start = time.Now()
+			for i := 0; i < 3; i++ {
+				mutex := rs.NewMutex(k+"-test-acquire-rate-limit-"+strconv.Itoa(i), limit)
+				err := mutex.Lock()
+				if err != nil {
+					t.Fatalf("mutex lock failed: %s", err)
+				}
+				defer mutex.Unlock()
+			}
+			if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+				t.Fatalf("Expected 3 acquisitions of mutexes sharing a limit of 10 rps to take at least 200ms, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestMutexSampledLock(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k + "-test-sampled-lock")
+
+			locked, err := mutex.SampledLock(context.Background(), 0)
+			if err != nil || locked {
+				t.Fatalf("Expected lock to be skipped, got %v, %v", locked, err)
+			}
+			if mutex.Value() != "" {
+				t.Fatalf("Expected no lock to be acquired")
+			}
+
+			locked, err = mutex.SampledLock(context.Background(), 1)
+			if err != nil || !locked {
+				t.Fatalf("Expected lock to be acquired, got %v, %v", locked, err)
+			}
+			assertAcquired(context.Background(), t, v.pools, mutex)
+			_, _ = mutex.Unlock()
+		})
+	}
+}
+
+func TestMutexServerSideTime(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-server-side-time", WithServerSideTime(true))
+
+			start := time.Now()
+			err := mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			defer mutex.Unlock()
+			assertAcquired(context.Background(), t, v.pools, mutex)
+
+			if until := mutex.Until(); !until.After(start) || until.After(time.Now().Add(mutex.expiry)) {
+				t.Fatalf("Expected until within lock expiry, got %s", until)
+			}
+		})
+	}
+}
+
+func TestMutexSoftLock(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			mutexes := newTestMutexes(v.pools, k+"-test-soft-lock", 1)
+			mutex := mutexes[0]
+			clogPools(v.pools, 0b0111, mutex)
+
+			locked, err := mutex.SoftLock(context.Background())
+			if err != nil || !locked {
+				t.Fatalf("Expected soft lock to be acquired, got %v, %v", locked, err)
+			}
+			if n := countAcquiredPools(context.Background(), v.pools, mutex); n != 1 {
+				t.Fatalf("Expected 1 acquired pool, got %d", n)
+			}
+			ok, err := mutex.Extend()
+			if err != nil || !ok {
+				t.Fatalf("mutex extend failed: %v", err)
+			}
+			ok, err = mutex.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+
+			clogPools(v.pools, 0b1111, mutex)
+			locked, err = mutex.SoftLock(context.Background())
+			if err == nil || locked {
+				t.Fatalf("Expected soft lock to fail, got %v, %v", locked, err)
+			}
+		})
+	}
+}
+
+func TestMutexStickyPool(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-sticky-pool", WithStickyPool(true))
+			clogPools(v.pools, 1, mutex)
+
+			for i := 0; i < 2; i++ {
+				err := mutex.Lock()
+				if err != nil {
+					t.Fatalf("mutex lock failed: %s", err)
+				}
+				if mutex.stickyNode < 1 || mutex.stickyNode >= len(v.pools) {
+					t.Fatalf("Expected sticky node in [1, %d), got %d", len(v.pools), mutex.stickyNode)
+				}
+				ok, err := mutex.Unlock()
+				if err != nil || !ok {
+					t.Fatalf("mutex unlock failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMutexBackoff(t *testing.T) {
+	rs := New()
+	mutex := rs.NewMutex("test-backoff", WithRetryDelayFunc(func(tries int) time.Duration {
+		return time.Duration(tries) * time.Second
+	}))
+	for attempt := 1; attempt < 4; attempt++ {
+		if delay := mutex.Backoff(attempt); delay != time.Duration(attempt)*time.Second {
+			t.Fatalf("Expected %s, got %s", time.Duration(attempt)*time.Second, delay)
+		}
+	}
+}
+
+func TestMutexReentrantContext(t *testing.T) {
+	type lockKey struct{}
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-reentrant-context", WithReentrantContext(lockKey{}), WithTries(1))
+
+			ctx := context.Background()
+			if AddLockContext(ctx, mutex) != ctx {
+				t.Fatalf("Expected context to be unchanged before locking")
+			}
+
+			err := mutex.LockContext(ctx)
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			value := mutex.Value()
+
+			lockCtx := AddLockContext(ctx, mutex)
+			err = mutex.LockContext(lockCtx)
+			if err != nil {
+				t.Fatalf("reentrant mutex lock failed: %s", err)
+			}
+			if mutex.Value() != value {
+				t.Fatalf("Expected value %q, got %q", value, mutex.Value())
+			}
+
+			err = mutex.LockContext(ctx)
+			if err == nil {
+				t.Fatalf("Expected lock without token to fail")
+			}
+
+			ok, err := mutex.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+			if AddLockContext(ctx, mutex) != ctx {
+				t.Fatalf("Expected context to be unchanged after unlocking")
+			}
+
+			// A context carrying the token of a released lock does not bypass the lock held by another client.
+			other := rs.NewMutex(k+"-test-reentrant-context", WithTries(1))
+			err = other.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			defer other.Unlock()
+			err = mutex.LockContext(lockCtx)
+			if err == nil {
+				t.Fatalf("Expected lock with the token of a released lock to fail")
+			}
+		})
+	}
+}
+
+func TestMutexLockWithEphemeralValue(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-ephemeral-value", WithRetryDelay(10*time.Millisecond))
+
+			var held int32
+			eg := errgroup.Group{}
+			for i := 0; i < 4; i++ {
+				eg.Go(func() error {
+					value, err := mutex.LockWithEphemeralValue(ctx)
+					if err != nil {
+						return err
+					}
+					if n := atomic.AddInt32(&held, 1); n != 1 {
+						return fmt.Errorf("Expected 1 holder, got %d", n)
+					}
+					atomic.AddInt32(&held, -1)
+					ok, err := mutex.UnlockWithValue(ctx, value)
+					if err != nil || !ok {
+						return fmt.Errorf("mutex unlock failed: %v", err)
+					}
+					return nil
+				})
+			}
+			if err := eg.Wait(); err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			if mutex.Value() != "" {
+				t.Fatalf("Expected mutex value to stay empty, got %q", mutex.Value())
+			}
+		})
+	}
+}
+
+func TestMutexVersionedLock(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-versioned-lock", WithVersionedLock(true))
+
+			var versions []int
+			for i := 0; i < 2; i++ {
+				err := mutex.Lock()
+				if err != nil {
+					t.Fatalf("mutex lock failed: %s", err)
+				}
+				assertAcquired(ctx, t, v.pools, mutex)
+
+				value := mutex.Value()
+				i := strings.LastIndex(value, ":v")
+				if i == -1 {
+					t.Fatalf("Expected versioned value, got %q", value)
+				}
+				version, err := strconv.Atoi(value[i+2:])
+				if err != nil {
+					t.Fatalf("Expected numeric version, got %q", value)
+				}
+				versions = append(versions, version)
+
+				ok, err := mutex.Unlock()
+				if err != nil || !ok {
+					t.Fatalf("mutex unlock failed: %v", err)
+				}
+			}
+			if versions[1] <= versions[0] {
+				t.Fatalf("Expected version to increase, got %v", versions)
+			}
+		})
+	}
+}
+
+func TestMutexUpdateTags(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			// The hash tag keeps the lock and its metadata hash in the same slot.
+			mutex := rs.NewMutex("{"+k+"-test-update-tags}", WithMetadataHash(map[string]string{"phase": "preparing", "owner": "test"}))
+
+			err := mutex.UpdateTags(ctx, map[string]string{"phase": "executing"})
+			if err != ErrLockNotHeld {
+				t.Fatalf("Expected err == %q, got %q", ErrLockNotHeld, err)
+			}
+
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			metaKey := mutex.name + ":meta"
+			assertPoolHashField(t, v.pools, metaKey, "phase", "preparing")
+
+			err = mutex.UpdateTags(ctx, map[string]string{"phase": "executing"})
+			if err != nil {
+				t.Fatalf("mutex update tags failed: %s", err)
+			}
+			assertPoolHashField(t, v.pools, metaKey, "phase", "executing")
+			assertPoolHashField(t, v.pools, metaKey, "owner", "test")
+			assertAcquired(ctx, t, v.pools, mutex)
+			for i, expiry := range getPoolExpiries(v.pools, metaKey) {
+				if expiry <= 0 {
+					t.Fatalf("Expected metadata TTL on pool %d, got %d", i, expiry)
+				}
+			}
+
+			ok, err := mutex.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+			assertPoolHashField(t, v.pools, metaKey, "phase", "")
+
+			// The updated tags are written by the next lock.
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			defer mutex.Unlock()
+			assertPoolHashField(t, v.pools, metaKey, "phase", "executing")
+			assertPoolHashField(t, v.pools, metaKey, "owner", "test")
+		})
+	}
+}
+
+func TestMutexMigrateKey(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			name := k + "-test-migrate-key"
+			for _, safe := range []bool{false, true} {
+				mutex := rs.NewMutex(name, WithSafeRename(safe))
+				err := mutex.Lock()
+				if err != nil {
+					t.Fatalf("mutex lock failed: %s", err)
+				}
+
+				err = mutex.MigrateKey(ctx, name+"-new")
+				if err != nil {
+					t.Fatalf("mutex migrate key failed: %s", err)
+				}
+				if mutex.Name() != name+"-new" {
+					t.Fatalf("Expected name %q, got %q", name+"-new", mutex.Name())
+				}
+				assertAcquired(ctx, t, v.pools, mutex)
+				for i, value := range getPoolValues(ctx, v.pools, name) {
+					if value != "" {
+						t.Fatalf("Expected old key to be removed on pool %d, got %q", i, value)
+					}
+				}
+
+				ok, err := mutex.Unlock()
+				if err != nil || !ok {
+					t.Fatalf("mutex unlock failed: %v", err)
+				}
+			}
+
+			mutex := rs.NewMutex(name, WithSafeRename(true))
+			err := mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			defer mutex.Unlock()
+			clogPools(v.pools, 0b1111, rs.NewMutex(name+"-taken"))
+			err = mutex.MigrateKey(ctx, name+"-taken")
+			if !errors.Is(err, ErrKeyExists) {
+				t.Fatalf("Expected err == %q, got %v", ErrKeyExists, err)
+			}
+			assertAcquired(ctx, t, v.pools, mutex)
+
+			// The key is moved on a minority of pools only: they are moved back.
+			clogPools(v.pools, 0b0011, rs.NewMutex(name+"-partial"))
+			err = mutex.MigrateKey(ctx, name+"-partial")
+			if !errors.Is(err, ErrKeyExists) {
+				t.Fatalf("Expected err == %q, got %v", ErrKeyExists, err)
+			}
+			if mutex.Name() != name {
+				t.Fatalf("Expected name %q, got %q", name, mutex.Name())
+			}
+			assertAcquired(ctx, t, v.pools, mutex)
+			for i, value := range getPoolValues(ctx, v.pools, name+"-partial")[2:] {
+				if value != "" {
+					t.Fatalf("Expected the key to be moved back on pool %d, got %q", i+2, value)
+				}
+			}
+		})
+	}
+}
+
+func TestMutexLockForever(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k + "-test-lock-forever")
+			other := rs.NewMutex(k+"-test-lock-forever", WithTries(1))
+
+			err := mutex.LockForever(ctx)
+			if err != nil {
+				t.Fatalf("mutex lock forever failed: %s", err)
+			}
+			assertAcquired(ctx, t, v.pools, mutex)
+			for i, expiry := range getPoolExpiries(v.pools, mutex.name) {
+				if expiry >= 0 {
+					t.Fatalf("Expected no expiry on pool %d, got %d", i, expiry)
+				}
+			}
+			ok, err := mutex.Extend()
+			if err != nil || !ok {
+				t.Fatalf("mutex extend failed: %v", err)
+			}
+			for i, expiry := range getPoolExpiries(v.pools, mutex.name) {
+				if expiry >= 0 {
+					t.Fatalf("Expected no expiry on pool %d after extend, got %d", i, expiry)
+				}
+			}
+
+			if err := other.Lock(); err == nil {
+				t.Fatalf("Expected the lock to be held forever")
+			}
+			ok, err = other.ForceUnlock(ctx)
+			if err != nil || !ok {
+				t.Fatalf("mutex force unlock failed: %v", err)
+			}
+			err = other.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			assertAcquired(ctx, t, v.pools, other)
+		})
+	}
+}
+
+func TestMutexForceUnlockHeld(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k+"-test-force-unlock-held", WithStateMachine(true))
+
+			err := mutex.LockForever(ctx)
+			if err != nil {
+				t.Fatalf("mutex lock forever failed: %s", err)
+			}
+			ok, err := mutex.ForceUnlock(ctx)
+			if err != nil || !ok {
+				t.Fatalf("mutex force unlock failed: %v", err)
+			}
+			if state := mutex.State(); state != StateUnlocked {
+				t.Fatalf("Expected state %v, got %v", StateUnlocked, state)
+			}
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			assertAcquired(ctx, t, v.pools, mutex)
+			ok, err = mutex.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestMutexLockWithPriority(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			// The hash tag keeps the lock and its wait queue in the same slot.
+			name := "{" + k + "-test-lock-with-priority}"
+			holder := rs.NewMutex(name)
+			err := holder.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+
+			order := make(chan int, 2)
+			var wg sync.WaitGroup
+			for _, priority := range []int{5, 1} {
+				wg.Add(1)
+				go func(priority int) {
+					defer wg.Done()
+					mutex := rs.NewMutex(name, WithTries(500), WithRetryDelay(10*time.Millisecond))
+					if err := mutex.LockWithPriority(ctx, priority); err != nil {
+						t.Errorf("mutex lock with priority failed: %s", err)
+						return
+					}
+					order <- priority
+					_, _ = mutex.Unlock()
+				}(priority)
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			ok, err := holder.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+			wg.Wait()
+			close(order)
+			var got []int
+			for priority := range order {
+				got = append(got, priority)
+			}
+			if !reflect.DeepEqual(got, []int{1, 5}) {
+				t.Fatalf("Expected the waiters to acquire the lock in order [1 5], got %v", got)
+			}
+		})
+	}
+}
+
+func TestMutexLastAcquireDetails(t *testing.T) {
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			mutex := rs.NewMutex(k + "-test-last-acquire-details")
+			other := rs.NewMutex(k + "-test-last-acquire-details")
+
+			err := mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			details := mutex.LastAcquireDetails()
+			if details.Attempt != 1 || details.FirstResponse <= 0 || details.QuorumResponse < details.FirstResponse ||
+				details.Confirmed < details.QuorumResponse {
+				t.Fatalf("Expected ordered response times, got %+v", details)
+			}
+			if details.Drift != time.Duration(float64(mutex.getExpiry())*mutex.driftFactor) || details.Validity <= 0 {
+				t.Fatalf("Expected drift and validity to be set, got %+v", details)
+			}
+			if len(details.Pools) != len(v.pools) {
+				t.Fatalf("Expected %d pool timings, got %d", len(v.pools), len(details.Pools))
+			}
+			for i, p := range details.Pools {
+				if p.Node != i || !p.Responded || !p.Acquired || p.Err != nil {
+					t.Fatalf("Expected pool %d to grant the lock, got %+v", i, p)
+				}
+			}
+
+			err = other.TryLock()
+			if err == nil {
+				t.Fatalf("Expected the lock to be taken")
+			}
+			details = other.LastAcquireDetails()
+			if details.QuorumResponse != 0 || details.FirstResponse <= 0 {
+				t.Fatalf("Expected no quorum response, got %+v", details)
+			}
+		})
+	}
+}
+
+func TestMutexIncrementalExtend(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(3) {
+		t.Run(k, func(t *testing.T) {
+			mutex := New(v.pools...).NewMutex(k+"-test-incremental-extend", WithExpiry(time.Second))
+			err := mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			time.Sleep(200 * time.Millisecond)
+
+			ok, err := mutex.IncrementalExtend(ctx, 500*time.Millisecond)
+			if err != nil || ok {
+				t.Fatalf("Expected the extension to be skipped, got %v, %v", ok, err)
+			}
+			for i, expiry := range getPoolExpiries(v.pools, mutex.name) {
+				if expiry > int(900*time.Millisecond) {
+					t.Fatalf("Expected the expiry on pool %d not to be reset, got %d", i, expiry)
+				}
+			}
+
+			ok, err = mutex.IncrementalExtend(ctx, 900*time.Millisecond)
+			if err != nil || !ok {
+				t.Fatalf("mutex incremental extend failed: %v", err)
+			}
+			for i, expiry := range getPoolExpiries(v.pools, mutex.name) {
+				if expiry <= int(900*time.Millisecond) {
+					t.Fatalf("Expected the expiry on pool %d to be reset, got %d", i, expiry)
+				}
+			}
+		})
+	}
+}
+
 func getPoolValues(ctx context.Context, pools []redis.Pool, name string) []string {
 	values := make([]string, len(pools))
 	for i, pool := range pools {
@@ -365,6 +1223,26 @@ func getPoolExpiries(pools []redis.Pool, name string) []int {
 	return expiries
 }
 
+var hgetScript = redis.NewScript(1, `return redis.call("HGET", KEYS[1], ARGV[1]) or ""`)
+
+func assertPoolHashField(t *testing.T, pools []redis.Pool, key, field, expected string) {
+	t.Helper()
+	for i, pool := range pools {
+		conn, err := pool.Get(context.Background())
+		if err != nil {
+			t.Fatalf("pool get failed: %s", err)
+		}
+		reply, err := conn.Eval(hgetScript, key, field)
+		_ = conn.Close()
+		if err != nil {
+			t.Fatalf("hget failed: %s", err)
+		}
+		if value := replyString(reply); value != expected {
+			t.Fatalf("Expected %s %q on pool %d to be %q, got %q", key, field, i, expected, value)
+		}
+	}
+}
+
 func clogPools(pools []redis.Pool, mask int, mutex *Mutex) int {
 	n := 0
 	for i, pool := range pools {
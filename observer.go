@@ -0,0 +1,64 @@
+package redsync
+
+// A RedsyncObserver is notified of the events of all the mutexes created by a Redsync instance. Its methods are called
+// synchronously from the goroutine operating on the mutex and should not block.
+type RedsyncObserver interface {
+	// MutexCreated is called when a mutex is created.
+	MutexCreated(name string)
+	// LockAcquired is called when a mutex acquires the lock with the given value.
+	LockAcquired(name, value string)
+	// LockFailed is called when a mutex fails to acquire the lock.
+	LockFailed(name string, err error)
+	// LockReleased is called when a mutex releases the lock.
+	LockReleased(name string)
+}
+
+// MultiRedsyncObserver is a RedsyncObserver that notifies each of its observers in turn.
+type MultiRedsyncObserver []RedsyncObserver
+
+// MutexCreated implements RedsyncObserver.
+func (o MultiRedsyncObserver) MutexCreated(name string) {
+	for _, observer := range o {
+		observer.MutexCreated(name)
+	}
+}
+
+// LockAcquired implements RedsyncObserver.
+func (o MultiRedsyncObserver) LockAcquired(name, value string) {
+	for _, observer := range o {
+		observer.LockAcquired(name, value)
+	}
+}
+
+// LockFailed implements RedsyncObserver.
+func (o MultiRedsyncObserver) LockFailed(name string, err error) {
+	for _, observer := range o {
+		observer.LockFailed(name, err)
+	}
+}
+
+// LockReleased implements RedsyncObserver.
+func (o MultiRedsyncObserver) LockReleased(name string) {
+	for _, observer := range o {
+		observer.LockReleased(name)
+	}
+}
+
+// WithObserver sets the observer notified of the events of the mutexes subsequently created by r, replacing any
+// observer set before. Use MultiRedsyncObserver to register several observers. It returns r.
+func (r *Redsync) WithObserver(o RedsyncObserver) *Redsync {
+	r.observer = o
+	return r
+}
+
+// observeLock notifies the observer of m, if any, of the outcome of a lock attempt.
+func (m *Mutex) observeLock(err error) {
+	if m.observer == nil {
+		return
+	}
+	if err != nil {
+		m.observer.LockFailed(m.name, err)
+	} else {
+		m.observer.LockAcquired(m.name, m.Value())
+	}
+}
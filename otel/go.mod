@@ -0,0 +1,27 @@
+module github.com/go-redsync/redsync/v4/otel
+
+go 1.22
+
+require (
+	github.com/go-redsync/redsync/v4 v4.19.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/hashicorp/errwrap v1.1.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/time v0.5.0 // indirect
+)
+
+// v4.19.0 is the first release of redsync with the APIs this module uses. The replace directive builds it against
+// the redsync sources of this repository until then.
+replace github.com/go-redsync/redsync/v4 => ..
@@ -0,0 +1,134 @@
+// Package otel provides OpenTelemetry tracing of the Redis commands issued by Redsync, at the pool level.
+package otel
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type pool struct {
+	delegate redis.Pool
+	tracer   trace.Tracer
+	attrs    []attribute.KeyValue
+}
+
+// TracedPool returns a pool that records a span with tracer for every command issued through inner. Spans have the
+// attributes redis.command, redis.key, redis.pool_name and redis.response_status. Use TracedPools to also record
+// redis.pool_index.
+func TracedPool(inner redis.Pool, tracer trace.Tracer, poolName string) redis.Pool {
+	return &pool{
+		delegate: inner,
+		tracer:   tracer,
+		attrs:    []attribute.KeyValue{attribute.String("redis.pool_name", poolName)},
+	}
+}
+
+// TracedPools wraps each of pools with TracedPool, additionally recording the position of the pool in the
+// redis.pool_index attribute.
+func TracedPools(pools []redis.Pool, tracer trace.Tracer, poolName string) []redis.Pool {
+	traced := make([]redis.Pool, len(pools))
+	for i, inner := range pools {
+		traced[i] = &pool{
+			delegate: inner,
+			tracer:   tracer,
+			attrs: []attribute.KeyValue{
+				attribute.String("redis.pool_name", poolName),
+				attribute.Int("redis.pool_index", i),
+			},
+		}
+	}
+	return traced
+}
+
+func (p *pool) Get(ctx context.Context) (redis.Conn, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c, err := p.delegate.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c, p, ctx}, nil
+}
+
+type conn struct {
+	delegate redis.Conn
+	pool     *pool
+	ctx      context.Context
+}
+
+func (c *conn) Get(name string) (string, error) {
+	span := c.start("GET", name)
+	value, err := c.delegate.Get(name)
+	end(span, status(value != "", err), err)
+	return value, err
+}
+
+func (c *conn) Set(name string, value string) (bool, error) {
+	span := c.start("SET", name)
+	ok, err := c.delegate.Set(name, value)
+	end(span, status(ok, err), err)
+	return ok, err
+}
+
+func (c *conn) SetNX(name string, value string, expiry time.Duration) (bool, error) {
+	span := c.start("SET", name)
+	ok, err := c.delegate.SetNX(name, value, expiry)
+	end(span, status(ok, err), err)
+	return ok, err
+}
+
+func (c *conn) PTTL(name string) (time.Duration, error) {
+	span := c.start("PTTL", name)
+	ttl, err := c.delegate.PTTL(name)
+	end(span, status(true, err), err)
+	return ttl, err
+}
+
+func (c *conn) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	var key string
+	if script.KeyCount > 0 && len(keysAndArgs) > 0 {
+		key, _ = keysAndArgs[0].(string)
+	}
+	span := c.start("EVAL", key)
+	reply, err := c.delegate.Eval(script, keysAndArgs...)
+	end(span, status(reply != nil, err), err)
+	return reply, err
+}
+
+func (c *conn) Close() error {
+	return c.delegate.Close()
+}
+
+func (c *conn) start(command, key string) trace.Span {
+	_, span := c.pool.tracer.Start(c.ctx, "redis."+command, trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(c.pool.attrs...)
+	span.SetAttributes(attribute.String("redis.command", command), attribute.String("redis.key", key))
+	return span
+}
+
+// status returns the redis.response_status of a command: "error" if it failed, "nil" if it returned a nil or false
+// reply, and "ok" otherwise.
+func status(ok bool, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case !ok:
+		return "nil"
+	}
+	return "ok"
+}
+
+func end(span trace.Span, status string, err error) {
+	span.SetAttributes(attribute.String("redis.response_status", status))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,54 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/go-redsync/redsync/v4/redis/memory"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ redis.Conn = (*conn)(nil)
+
+var _ redis.Pool = (*pool)(nil)
+
+func TestTracedPools(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("redsync")
+	pools := TracedPools([]redis.Pool{memory.NewPool(), memory.NewPool()}, tracer, "locks")
+
+	mutex := redsync.New(pools...).NewMutex("test-traced-pools")
+	err := mutex.LockContext(context.Background())
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	indexes := map[int64]bool{}
+	for _, span := range spans {
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		if span.Name() != "redis.SET" || attrs["redis.command"].AsString() != "SET" {
+			t.Fatalf("Expected SET span, got %q", span.Name())
+		}
+		if attrs["redis.key"].AsString() != "test-traced-pools" || attrs["redis.pool_name"].AsString() != "locks" {
+			t.Fatalf("Unexpected attributes %v", span.Attributes())
+		}
+		if attrs["redis.response_status"].AsString() != "ok" {
+			t.Fatalf("Expected ok status, got %q", attrs["redis.response_status"].AsString())
+		}
+		indexes[attrs["redis.pool_index"].AsInt64()] = true
+	}
+	if !indexes[0] || !indexes[1] {
+		t.Fatalf("Expected spans for pools 0 and 1, got %v", indexes)
+	}
+}
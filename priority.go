@@ -0,0 +1,122 @@
+package redsync
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// priorityBand is the width of the range of wait queue scores given to each priority. Within a band, waiters are
+// ordered by the time, in milliseconds, at which they were queued.
+const priorityBand = 1e9
+
+// A waiter is an entry in the wait queue of a mutex, carried by the context of LockWithPriority.
+type waiter struct {
+	id    string
+	score int64
+}
+
+type waiterKey struct{}
+
+// priorityAcquireScript queues ARGV[4] with score ARGV[3] in the wait queue KEYS[2] of KEYS[1], and sets KEYS[1] to
+// ARGV[1] if it is not set and the waiter is at the head of the queue. Waiters that have not attempted to lock within
+// ARGV[2] milliseconds of ARGV[5], the current time, are assumed to be gone and are dropped from the queue: the
+// deadlines of the waiters are kept in the hash KEYS[3].
+var priorityAcquireScript = redis.NewScript(3, `
+	local queue = KEYS[2]
+	local alive = KEYS[3]
+	local now = tonumber(ARGV[5])
+	redis.call("ZADD", queue, "NX", ARGV[3], ARGV[4])
+	redis.call("HSET", alive, ARGV[4], now + tonumber(ARGV[2]))
+	redis.call("PEXPIRE", queue, ARGV[2])
+	redis.call("PEXPIRE", alive, ARGV[2])
+	while true do
+		local head = redis.call("ZRANGE", queue, 0, 0)[1]
+		if head == ARGV[4] then
+			break
+		end
+		local deadline = tonumber(redis.call("HGET", alive, head))
+		if deadline and deadline > now then
+			return 0
+		end
+		redis.call("ZREM", queue, head)
+		redis.call("HDEL", alive, head)
+	end
+	if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+		redis.call("ZREM", queue, ARGV[4])
+		redis.call("HDEL", alive, ARGV[4])
+		return 1
+	end
+	return 0
+`)
+
+// priorityDequeueScript removes ARGV[1] from the wait queue KEYS[1] and its hash of deadlines KEYS[2].
+var priorityDequeueScript = redis.NewScript(2, `
+	redis.call("ZREM", KEYS[1], ARGV[1])
+	redis.call("HDEL", KEYS[2], ARGV[1])
+	return 1
+`)
+
+// LockWithPriority locks m like LockContext, but first joins a wait queue held in Redis alongside the lock, and only
+// attempts to acquire the lock while it is at the head of the queue. Waiters are ordered by priority, then by the time
+// they called LockWithPriority: priority 0 is served first, and a waiter with a lower priority value jumps ahead of
+// all waiters with a higher one. Waiters that stop retrying for longer than the expiry of m lose their place.
+//
+// The queue is only honoured by the callers of LockWithPriority; Lock and TryLock may still acquire the lock ahead of
+// the queue. The queue is held in the keys "<name>:queue" and "<name>:queue:alive", which are accessed by the same
+// scripts as the lock: with Redis Cluster, or with the rueidis driver, which refuses multi-key commands across slots,
+// the name of the mutex must be a hash tag, such as "{name}", or contain one.
+func (m *Mutex) LockWithPriority(ctx context.Context, priority int) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	id, err := genValue()
+	if err != nil {
+		return err
+	}
+	w := &waiter{
+		id:    id,
+		score: int64(priority)*priorityBand + time.Now().UnixMilli(),
+	}
+	err = m.lockContext(context.WithValue(ctx, waiterKey{}, w), m.tries, 0)
+
+	// Leave the queue on the pools that did not grant the lock, or on all pools if it was not acquired.
+	_, _ = func() (int, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Duration(int64(float64(m.getExpiry())*m.timeoutFactor)))
+		defer cancel()
+		return m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+			conn, err := pool.Get(ctx)
+			if err != nil {
+				return false, err
+			}
+			defer conn.Close()
+			queue := m.queueKey()
+			_, err = conn.Eval(priorityDequeueScript, queue, queue+":alive", w.id)
+			return err == nil, err
+		})
+	}()
+	return err
+}
+
+// setNX sets the lock to value if it is not set. If ctx carries a waiter, the lock is only set if the waiter is at the
+// head of the wait queue.
+func (m *Mutex) setNX(ctx context.Context, conn redis.Conn, value string) (bool, error) {
+	w, ok := ctx.Value(waiterKey{}).(*waiter)
+	if !ok {
+		return conn.SetNX(m.name, value, m.getExpiry())
+	}
+	queue := m.queueKey()
+	reply, err := conn.Eval(priorityAcquireScript, m.name, queue, queue+":alive", value,
+		int(m.getExpiry()/time.Millisecond), strconv.FormatInt(w.score, 10), w.id, time.Now().UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	return replyInt64(reply) == 1, nil
+}
+
+// queueKey returns the key of the wait queue of m.
+func (m *Mutex) queueKey() string {
+	return m.name + ":queue"
+}
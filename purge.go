@@ -0,0 +1,97 @@
+package redsync
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/hashicorp/go-multierror"
+)
+
+// purgeScanCount is the COUNT hint passed to SCAN by Purge.
+const purgeScanCount = 100
+
+// purgeScript scans one batch of keys matching ARGV[2] from cursor ARGV[1] and deletes those holding a value in the
+// format generated by redsync: 16 random bytes in standard base64, optionally followed by a ":v{version}" suffix (see
+// WithVersionedLock). It returns the next cursor and the number of deleted keys.
+var purgeScript = redis.NewScript(0, `
+	local function managed(key)
+		if redis.call("TYPE", key)["ok"] ~= "string" then
+			return false
+		end
+		local token, suffix = string.match(redis.call("GET", key), "^([%w%+/]+==)(.*)$")
+		return token ~= nil and string.len(token) == 24 and (suffix == "" or string.match(suffix, "^:v%d+$") ~= nil)
+	end
+
+	local reply = redis.call("SCAN", ARGV[1], "MATCH", ARGV[2], "COUNT", ARGV[3])
+	local deleted = 0
+	for _, key in ipairs(reply[2]) do
+		if managed(key) then
+			deleted = deleted + redis.call("DEL", key)
+		end
+	end
+	return {reply[1], deleted}
+`)
+
+// Purge deletes the lock keys matching the glob-style pattern from all pools and returns the number of keys deleted
+// across pools. Only keys holding values in the format generated by redsync are deleted; locks using custom values
+// (see WithGenValueFunc) are left in place.
+//
+// Purge releases locks regardless of who holds them, breaking mutual exclusion for any process relying on them. It is
+// meant for tests and recovery after outages, not for production use while lock holders are running. It scans the
+// whole keyspace of each pool, which may be slow on large databases, and does not support Redis Cluster.
+func (r *Redsync) Purge(ctx context.Context, pattern string) (int, error) {
+	deleted := make([]int, len(r.pools))
+	errs := make([]error, len(r.pools))
+
+	var wg sync.WaitGroup
+	for node, pool := range r.pools {
+		wg.Add(1)
+		go func(node int, pool redis.Pool) {
+			defer wg.Done()
+			deleted[node], errs[node] = purge(ctx, pool, pattern)
+		}(node, pool)
+	}
+	wg.Wait()
+
+	var (
+		n   int
+		err error
+	)
+	for node := range r.pools {
+		n += deleted[node]
+		if errs[node] != nil {
+			err = multierror.Append(err, &RedisError{Node: node, Err: errs[node]})
+		}
+	}
+	return n, err
+}
+
+func purge(ctx context.Context, pool redis.Pool, pattern string) (int, error) {
+	conn, err := pool.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	deleted := 0
+	cursor := "0"
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		reply, err := conn.Eval(purgeScript, cursor, pattern, purgeScanCount)
+		if err != nil {
+			return deleted, err
+		}
+		values := replySlice(reply)
+		if len(values) != 2 {
+			return deleted, errUnexpectedReply
+		}
+		cursor = replyString(values[0])
+		deleted += int(replyInt64(values[1]))
+		if cursor == "0" {
+			return deleted, nil
+		}
+	}
+}
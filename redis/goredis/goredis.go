@@ -26,6 +26,19 @@ func NewPool(delegate *redis.Client) redsyncredis.Pool {
 	return &pool{delegate}
 }
 
+// PSubscribe subscribes to the channels matching pattern. See redsyncredis.Subscriber.
+func (p *pool) PSubscribe(ctx context.Context, pattern string) (<-chan redsyncredis.Message, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pubsub := p.delegate.PSubscribe(pattern)
+	if _, err := pubsub.Receive(); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	return forward(ctx, pubsub), nil
+}
+
 type conn struct {
 	delegate *redis.Client
 }
@@ -80,3 +93,29 @@ func noErrNil(err error) error {
 	}
 	return nil
 }
+
+// forward delivers the messages received by pubsub until ctx is done, then closes it.
+func forward(ctx context.Context, pubsub *redis.PubSub) <-chan redsyncredis.Message {
+	ch := make(chan redsyncredis.Message)
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+		msgs := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case ch <- redsyncredis.Message{Channel: msg.Channel, Pattern: msg.Pattern, Payload: msg.Payload}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
@@ -25,6 +25,19 @@ func NewPool(delegate redis.UniversalClient) redsyncredis.Pool {
 	return &pool{delegate}
 }
 
+// PSubscribe subscribes to the channels matching pattern. See redsyncredis.Subscriber.
+func (p *pool) PSubscribe(ctx context.Context, pattern string) (<-chan redsyncredis.Message, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pubsub := p.delegate.PSubscribe(ctx, pattern)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	return forward(ctx, pubsub), nil
+}
+
 type conn struct {
 	delegate redis.UniversalClient
 	ctx      context.Context
@@ -77,3 +90,29 @@ func noErrNil(err error) error {
 	}
 	return err
 }
+
+// forward delivers the messages received by pubsub until ctx is done, then closes it.
+func forward(ctx context.Context, pubsub *redis.PubSub) <-chan redsyncredis.Message {
+	ch := make(chan redsyncredis.Message)
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+		msgs := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case ch <- redsyncredis.Message{Channel: msg.Channel, Pattern: msg.Pattern, Payload: msg.Payload}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,378 @@
+// Package memory provides an in-memory pool implementation for tests. It emulates the subset of Redis used by the
+// lock, unlock and extend operations, including Lua scripts, without requiring a Redis server or any network access.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	redsyncredis "github.com/go-redsync/redsync/v4/redis"
+	lua "github.com/yuin/gopher-lua"
+)
+
+type entry struct {
+	value    string
+	expireAt time.Time
+}
+
+type pool struct {
+	mu    sync.Mutex
+	data  map[string]entry
+	state *lua.LState
+}
+
+func (p *pool) Get(ctx context.Context) (redsyncredis.Conn, error) {
+	return &conn{p}, nil
+}
+
+// NewPool returns an empty in-memory pool implementation. Each pool holds its own keyspace. Keys expire lazily,
+// according to the wall clock.
+//
+// Supported commands, both directly and through redis.call and redis.pcall in scripts, are GET, SET (with the NX, XX,
+// PX and EX options), DEL, EXISTS, PEXPIRE, EXPIRE, PTTL, PERSIST and INCR.
+func NewPool() redsyncredis.Pool {
+	return &pool{data: map[string]entry{}}
+}
+
+type conn struct {
+	pool *pool
+}
+
+func (c *conn) Get(name string) (string, error) {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	v, err := c.pool.do("GET", name)
+	s, _ := v.(string)
+	return s, err
+}
+
+func (c *conn) Set(name string, value string) (bool, error) {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	v, err := c.pool.do("SET", name, value)
+	return v == statusOK, err
+}
+
+func (c *conn) SetNX(name string, value string, expiry time.Duration) (bool, error) {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	v, err := c.pool.do("SET", name, value, "NX", "PX", strconv.FormatInt(int64(expiry/time.Millisecond), 10))
+	return v == statusOK, err
+}
+
+func (c *conn) PTTL(name string) (time.Duration, error) {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	v, err := c.pool.do("PTTL", name)
+	n, _ := v.(int64)
+	return time.Duration(n) * time.Millisecond, err
+}
+
+func (c *conn) Eval(script *redsyncredis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+	return c.pool.eval(script, keysAndArgs)
+}
+
+func (c *conn) Close() error {
+	// Not needed for this library
+	return nil
+}
+
+// lookup returns the live entry for key, dropping it if it has expired.
+func (p *pool) lookup(key string, now time.Time) (entry, bool) {
+	e, ok := p.data[key]
+	if ok && !e.expireAt.IsZero() && !now.Before(e.expireAt) {
+		delete(p.data, key)
+		return entry{}, false
+	}
+	return e, ok
+}
+
+var errSyntax = errors.New("ERR syntax error")
+
+// A statusReply is a status reply, such as the OK of SET, as opposed to a bulk reply which may hold the same string.
+type statusReply string
+
+const statusOK statusReply = "OK"
+
+// do executes a single command. The reply is nil, an int64, a string, a statusReply or an error, mirroring Redis' nil,
+// integer, bulk, status and error replies.
+func (p *pool) do(cmd string, args ...string) (interface{}, error) {
+	now := time.Now()
+	switch strings.ToUpper(cmd) {
+	case "GET":
+		if len(args) != 1 {
+			return nil, errArity(cmd)
+		}
+		e, ok := p.lookup(args[0], now)
+		if !ok {
+			return nil, nil
+		}
+		return e.value, nil
+
+	case "SET":
+		if len(args) < 2 {
+			return nil, errArity(cmd)
+		}
+		var (
+			nx, xx   bool
+			expireAt time.Time
+		)
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "XX":
+				xx = true
+			case "PX", "EX":
+				if i+1 >= len(args) {
+					return nil, errSyntax
+				}
+				n, err := strconv.ParseInt(args[i+1], 10, 64)
+				if err != nil || n <= 0 {
+					return nil, errors.New("ERR invalid expire time in 'set' command")
+				}
+				unit := time.Millisecond
+				if strings.ToUpper(args[i]) == "EX" {
+					unit = time.Second
+				}
+				expireAt = now.Add(time.Duration(n) * unit)
+				i++
+			default:
+				return nil, errSyntax
+			}
+		}
+		_, exists := p.lookup(args[0], now)
+		if (nx && exists) || (xx && !exists) {
+			return nil, nil
+		}
+		p.data[args[0]] = entry{value: args[1], expireAt: expireAt}
+		return statusOK, nil
+
+	case "DEL", "EXISTS":
+		if len(args) < 1 {
+			return nil, errArity(cmd)
+		}
+		var n int64
+		for _, key := range args {
+			if _, ok := p.lookup(key, now); ok {
+				if strings.ToUpper(cmd) == "DEL" {
+					delete(p.data, key)
+				}
+				n++
+			}
+		}
+		return n, nil
+
+	case "PEXPIRE", "EXPIRE":
+		if len(args) != 2 {
+			return nil, errArity(cmd)
+		}
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return nil, errors.New("ERR value is not an integer or out of range")
+		}
+		e, ok := p.lookup(args[0], now)
+		if !ok {
+			return int64(0), nil
+		}
+		unit := time.Millisecond
+		if strings.ToUpper(cmd) == "EXPIRE" {
+			unit = time.Second
+		}
+		if n <= 0 {
+			delete(p.data, args[0])
+			return int64(1), nil
+		}
+		e.expireAt = now.Add(time.Duration(n) * unit)
+		p.data[args[0]] = e
+		return int64(1), nil
+
+	case "PTTL":
+		if len(args) != 1 {
+			return nil, errArity(cmd)
+		}
+		e, ok := p.lookup(args[0], now)
+		if !ok {
+			return int64(-2), nil
+		}
+		if e.expireAt.IsZero() {
+			return int64(-1), nil
+		}
+		return int64(e.expireAt.Sub(now) / time.Millisecond), nil
+
+	case "PERSIST":
+		if len(args) != 1 {
+			return nil, errArity(cmd)
+		}
+		e, ok := p.lookup(args[0], now)
+		if !ok || e.expireAt.IsZero() {
+			return int64(0), nil
+		}
+		e.expireAt = time.Time{}
+		p.data[args[0]] = e
+		return int64(1), nil
+
+	case "INCR":
+		if len(args) != 1 {
+			return nil, errArity(cmd)
+		}
+		e, _ := p.lookup(args[0], now)
+		var n int64
+		if e.value != "" {
+			var err error
+			n, err = strconv.ParseInt(e.value, 10, 64)
+			if err != nil {
+				return nil, errors.New("ERR value is not an integer or out of range")
+			}
+		}
+		n++
+		e.value = strconv.FormatInt(n, 10)
+		p.data[args[0]] = e
+		return n, nil
+	}
+	return nil, fmt.Errorf("ERR unknown command '%s'", cmd)
+}
+
+func errArity(cmd string) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd))
+}
+
+func (p *pool) eval(script *redsyncredis.Script, keysAndArgs []interface{}) (interface{}, error) {
+	if p.state == nil {
+		p.state = lua.NewState()
+		redisTable := p.state.NewTable()
+		p.state.SetField(redisTable, "call", p.state.NewFunction(p.luaCall(false)))
+		p.state.SetField(redisTable, "pcall", p.state.NewFunction(p.luaCall(true)))
+		p.state.SetGlobal("redis", redisTable)
+	}
+	L := p.state
+
+	keyCount := script.KeyCount
+	if keyCount < 0 {
+		if len(keysAndArgs) == 0 {
+			return nil, errArity("eval")
+		}
+		n, err := strconv.Atoi(fmt.Sprint(keysAndArgs[0]))
+		if err != nil {
+			return nil, errors.New("ERR value is not an integer or out of range")
+		}
+		keyCount, keysAndArgs = n, keysAndArgs[1:]
+	}
+	if keyCount > len(keysAndArgs) {
+		return nil, errors.New("ERR Number of keys can't be greater than number of args")
+	}
+	keys, args := L.NewTable(), L.NewTable()
+	for i, v := range keysAndArgs {
+		if i < keyCount {
+			keys.Append(lua.LString(fmt.Sprint(v)))
+		} else {
+			args.Append(lua.LString(fmt.Sprint(v)))
+		}
+	}
+	L.SetGlobal("KEYS", keys)
+	L.SetGlobal("ARGV", args)
+
+	fn, err := L.LoadString(script.Src)
+	if err != nil {
+		return nil, fmt.Errorf("ERR Error compiling script: %s", err)
+	}
+	L.Push(fn)
+	if err := L.PCall(0, 1, nil); err != nil {
+		if apiErr, ok := err.(*lua.ApiError); ok {
+			if s, ok := apiErr.Object.(lua.LString); ok {
+				return nil, errors.New(string(s))
+			}
+		}
+		return nil, fmt.Errorf("ERR Error running script: %s", err)
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+	return fromLua(ret)
+}
+
+// luaCall returns the implementation of redis.call, or redis.pcall if protected is true.
+func (p *pool) luaCall(protected bool) lua.LGFunction {
+	return func(L *lua.LState) int {
+		n := L.GetTop()
+		if n == 0 {
+			L.RaiseError("Please specify at least one argument for redis.call()")
+		}
+		args := make([]string, n)
+		for i := 1; i <= n; i++ {
+			switch v := L.Get(i).(type) {
+			case lua.LString:
+				args[i-1] = string(v)
+			case lua.LNumber:
+				args[i-1] = strconv.FormatInt(int64(v), 10)
+			default:
+				L.RaiseError("Lua redis() command arguments must be strings or integers")
+			}
+		}
+		reply, err := p.do(args[0], args[1:]...)
+		if err != nil {
+			if !protected {
+				L.Error(lua.LString(err.Error()), 0)
+			}
+			t := L.NewTable()
+			L.SetField(t, "err", lua.LString(err.Error()))
+			L.Push(t)
+			return 1
+		}
+		switch reply := reply.(type) {
+		case nil:
+			L.Push(lua.LFalse)
+		case int64:
+			L.Push(lua.LNumber(reply))
+		case string:
+			L.Push(lua.LString(reply))
+		case statusReply:
+			t := L.NewTable()
+			L.SetField(t, "ok", lua.LString(reply))
+			L.Push(t)
+		}
+		return 1
+	}
+}
+
+// fromLua converts a script's return value to a reply following the Redis conversion rules.
+func fromLua(v lua.LValue) (interface{}, error) {
+	switch v := v.(type) {
+	case lua.LNumber:
+		return int64(v), nil
+	case lua.LString:
+		return string(v), nil
+	case lua.LBool:
+		if v {
+			return int64(1), nil
+		}
+		return nil, nil
+	case *lua.LTable:
+		if err, ok := v.RawGetString("err").(lua.LString); ok {
+			return nil, errors.New(string(err))
+		}
+		if ok, isStatus := v.RawGetString("ok").(lua.LString); isStatus {
+			return string(ok), nil
+		}
+		var values []interface{}
+		for i := 1; ; i++ {
+			elem := v.RawGetInt(i)
+			if elem == lua.LNil {
+				break
+			}
+			value, err := fromLua(elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+	return nil, nil
+}
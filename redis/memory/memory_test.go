@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+var _ redis.Conn = (*conn)(nil)
+
+var _ redis.Pool = (*pool)(nil)
+
+func TestConn(t *testing.T) {
+	conn, err := NewPool().Get(context.Background())
+	if err != nil {
+		t.Fatalf("pool get failed: %s", err)
+	}
+	defer conn.Close()
+
+	ok, err := conn.SetNX("key", "value", 100*time.Millisecond)
+	if err != nil || !ok {
+		t.Fatalf("Expected SetNX to succeed, got %v, %v", ok, err)
+	}
+	ok, err = conn.SetNX("key", "other", 100*time.Millisecond)
+	if err != nil || ok {
+		t.Fatalf("Expected SetNX to fail, got %v, %v", ok, err)
+	}
+	value, err := conn.Get("key")
+	if err != nil || value != "value" {
+		t.Fatalf("Expected %q, got %q, %v", "value", value, err)
+	}
+	ttl, err := conn.PTTL("key")
+	if err != nil || ttl <= 0 || ttl > 100*time.Millisecond {
+		t.Fatalf("Expected 0 < ttl <= 100ms, got %s, %v", ttl, err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	value, err = conn.Get("key")
+	if err != nil || value != "" {
+		t.Fatalf("Expected key to expire, got %q, %v", value, err)
+	}
+}
+
+func TestConnEval(t *testing.T) {
+	conn, err := NewPool().Get(context.Background())
+	if err != nil {
+		t.Fatalf("pool get failed: %s", err)
+	}
+	defer conn.Close()
+
+	script := redis.NewScript(1, `
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("DEL", KEYS[1])
+		elseif redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2], "NX") then
+			return {ARGV[1], redis.call("PTTL", KEYS[1])}
+		end
+		return 0
+	`)
+
+	reply, err := conn.Eval(script, "key", "value", 1000)
+	if err != nil {
+		t.Fatalf("eval failed: %s", err)
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 || values[0] != "value" || values[1].(int64) <= 0 {
+		t.Fatalf("Expected [value ttl], got %#v", reply)
+	}
+	reply, err = conn.Eval(script, "key", "value", 1000)
+	if err != nil || reply != int64(1) {
+		t.Fatalf("Expected 1, got %#v, %v", reply, err)
+	}
+
+	// A value that reads like a status reply is still returned as a bulk reply.
+	_, err = conn.Set("ok", "OK")
+	if err != nil {
+		t.Fatalf("set failed: %s", err)
+	}
+	reply, err = conn.Eval(redis.NewScript(1, `return redis.call("GET", KEYS[1]) == "OK"`), "ok")
+	if err != nil || reply != int64(1) {
+		t.Fatalf("Expected 1, got %#v, %v", reply, err)
+	}
+
+	_, err = conn.Eval(redis.NewScript(0, `return redis.call("NOSUCHCOMMAND")`))
+	if err == nil {
+		t.Fatalf("Expected an error for an unknown command")
+	}
+}
@@ -34,6 +34,63 @@ func NewPool(delegate Pool) redsyncredis.Pool {
 	return &pool{delegate}
 }
 
+// PSubscribe subscribes to the channels matching pattern on a dedicated connection. See redsyncredis.Subscriber.
+func (p *pool) PSubscribe(ctx context.Context, pattern string) (<-chan redsyncredis.Message, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c, err := p.delegate.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	psc := redis.PubSubConn{Conn: c}
+	if err := psc.PSubscribe(pattern); err != nil {
+		_ = psc.Close()
+		return nil, err
+	}
+	if err, ok := psc.Receive().(error); ok {
+		_ = psc.Close()
+		return nil, err
+	}
+
+	ch := make(chan redsyncredis.Message)
+	done := make(chan struct{})
+	unsubscribed := make(chan struct{})
+	go func() {
+		// Unsubscribing, which is safe while Receive is running, makes Receive return.
+		defer close(unsubscribed)
+		select {
+		case <-ctx.Done():
+			_ = psc.PUnsubscribe()
+		case <-done:
+		}
+	}()
+	go func() {
+		defer close(ch)
+		defer func() {
+			close(done)
+			<-unsubscribed
+			_ = psc.Close()
+		}()
+		for {
+			switch v := psc.Receive().(type) {
+			case redis.Message:
+				select {
+				case ch <- redsyncredis.Message{Channel: v.Channel, Pattern: v.Pattern, Payload: string(v.Data)}:
+				case <-ctx.Done():
+				}
+			case redis.Subscription:
+				if v.Count == 0 {
+					return
+				}
+			case error:
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
 type conn struct {
 	delegate redis.Conn
 }
@@ -23,6 +23,20 @@ type Conn interface {
 	Close() error
 }
 
+// Subscriber is implemented by pools that support Redis Pub/Sub.
+type Subscriber interface {
+	// PSubscribe subscribes to the channels matching pattern. Messages are delivered on the returned channel, which is
+	// closed once ctx is done or the subscription fails.
+	PSubscribe(ctx context.Context, pattern string) (<-chan Message, error)
+}
+
+// Message is a message received on a Pub/Sub channel.
+type Message struct {
+	Channel string
+	Pattern string
+	Payload string
+}
+
 // Script encapsulates the source, hash and key count for a Lua script.
 // Taken from https://github.com/gomodule/redigo/blob/46992b0f02f74066bcdfd9b03e33bc03abd10dc7/redis/script.go#L24-L30
 type Script struct {
@@ -1,10 +1,14 @@
 package redsync
 
 import (
+	"context"
 	"math/rand"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-redsync/redsync/v4/redis"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -27,12 +31,22 @@ type Redsync struct {
 	//简单来说就是单个client，内部有connPool，connPool内部有[]conns，每个请求获取连接的时候，先从client找connPool,然后找conns
 	
 	
+	// dedupPools wrap pools for mutexes created with WithCommandDedup. They are shared so that commands can be merged
+	// across mutexes.
+	dedupPools []redis.Pool
+
+	observer RedsyncObserver
 }
 
 // New creates and returns a new Redsync instance from given Redis connection pools.
 func New(pools ...redis.Pool) *Redsync {
+	dedupPools := make([]redis.Pool, len(pools))
+	for i, pool := range pools {
+		dedupPools[i] = newDedupPool(pool)
+	}
 	return &Redsync{
-		pools: pools,
+		pools:      pools,
+		dedupPools: dedupPools,
 	}
 }
 
@@ -50,17 +64,78 @@ func (r *Redsync) NewMutex(name string, options ...Option) *Mutex {
 		driftFactor:   0.01,
 		timeoutFactor: 0.05,
 		quorum:        len(r.pools)/2 + 1,
+		observer:      r.observer,
+		stickyNode:    -1,
 		pools:         r.pools,
 	}
 	for _, o := range options {
 		o.Apply(m)
 	}
+	if m.commandDedup {
+		m.pools = append([]redis.Pool(nil), r.dedupPools...)
+	}
 	if m.shuffle {
 		randomPools(m.pools)
 	}
+	if m.observer != nil {
+		m.observer.MutexCreated(name)
+	}
 	return m
 }
 
+// NewMutexPool returns size distributed mutexes named baseName:0 through baseName:size-1, all configured with the
+// given options. It is useful for distributed semaphores with named slots or sets of named worker slots.
+func (r *Redsync) NewMutexPool(size int, baseName string, options ...Option) []*Mutex {
+	mutexes := make([]*Mutex, size)
+	for i := range mutexes {
+		mutexes[i] = r.NewMutex(baseName+":"+strconv.Itoa(i), options...)
+	}
+	return mutexes
+}
+
+// TryLockAll tries once to lock a mutex for each of the given names, concurrently, and returns the mutexes in the same
+// order if all of them were locked. Otherwise, the locks that were acquired are released and an *ErrPartialLock
+// listing the available and unavailable names is returned.
+func (r *Redsync) TryLockAll(ctx context.Context, names []string, options ...Option) ([]*Mutex, error) {
+	mutexes := make([]*Mutex, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		mutexes[i] = r.NewMutex(name, options...)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = mutexes[i].TryLockContext(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	partial := &ErrPartialLock{}
+	for i, err := range errs {
+		if err != nil {
+			partial.Unavailable = append(partial.Unavailable, names[i])
+		} else {
+			partial.Available = append(partial.Available, names[i])
+		}
+	}
+	if len(partial.Unavailable) == 0 {
+		return mutexes, nil
+	}
+
+	for i, err := range errs {
+		if err == nil {
+			wg.Add(1)
+			go func(m *Mutex) {
+				defer wg.Done()
+				_, _ = m.UnlockContext(ctx)
+			}(mutexes[i])
+		}
+	}
+	wg.Wait()
+	return nil, partial
+}
+
 // An Option configures a mutex.
 type Option interface {
 	Apply(*Mutex)
@@ -167,6 +242,167 @@ func WithShufflePools(b bool) Option {
 	})
 }
 
+// WithStateMachine can be used to track the lifecycle of a mutex (created, locking, locked, unlocking, unlocked) and
+// reject operations that are not valid in the current state with ErrInvalidTransition. This catches usage bugs such as
+// unlocking a mutex that was never locked, unlocking twice or extending while unlocking.
+func WithStateMachine(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.stateMachine = b
+	})
+}
+
+// WithStickyPool can be used to attempt to lock on the pool that was first to grant the previous lock before the
+// others: the other pools are dispatched to once the preferred pool has replied, or after a quarter of the timeout of
+// the attempt if it is slow to reply. The preferred pool is remembered across Lock/Unlock cycles of the same mutex, and
+// forgotten when an attempt fails. This trades the latency of a round trip to the preferred pool for less contention
+// when the lock is usually won on the same pool.
+func WithStickyPool(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.stickyPool = b
+	})
+}
+
+// WithLockRegistry can be used to list the mutex in the given registry while its lock is held.
+func WithLockRegistry(registry *LockRegistry) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.registry = registry
+	})
+}
+
+// WithReentrantContext can be used to make locking reentrant for callers whose context carries the lock's token under
+// the given key, as injected by AddLockContext. Such calls return immediately without contacting Redis while the lock
+// is held. Reentrant calls are not counted: the first Unlock releases the lock.
+func WithReentrantContext(key interface{}) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.reentrantKey = key
+	})
+}
+
+// WithVersionedLock can be used to suffix lock values with a version of the form ":v{version}", where version is
+// obtained by incrementing the "<name>:version" key on the mutex's pools before each acquisition. Values of locks that
+// have expired and been acquired again can then never be confused with the current one.
+func WithVersionedLock(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.versionedLock = b
+	})
+}
+
+// WithCommandDedup can be used to merge simultaneous script evaluations with identical scripts, keys and arguments
+// issued by mutexes of the same Redsync instance into a single Redis command, returning the same result to all
+// callers. This reduces Redis load when many goroutines operate on the same lock at once. Only the scripts that are
+// read-only or idempotent, such as those releasing or extending a lock, are merged: version increments are not.
+func WithCommandDedup(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.commandDedup = b
+	})
+}
+
+// WithAcquireRateLimit can be used to limit lock acquisition attempts to rps per second. Attempts beyond the limit
+// block until allowed, protecting Redis from runaway retry loops. The limit is shared by all the mutexes the returned
+// option is applied to, so that reusing the option, as with NewMutexPool, limits the client as a whole; call
+// WithAcquireRateLimit again for a separate limit.
+func WithAcquireRateLimit(rps float64) Option {
+	limiter := rate.NewLimiter(rate.Limit(rps), 1)
+	return OptionFunc(func(m *Mutex) {
+		m.acquireLimiter = limiter
+	})
+}
+
+// WithServerSideTime can be used to measure the time spent acquiring the lock with the Redis servers' clocks, by
+// issuing TIME on each pool before and after SET NX, rather than with the client's clock. This reduces the impact of
+// client clock skew on the computed validity of the lock. It does not account for network latency before the first
+// TIME command and costs two extra round trips per pool.
+func WithServerSideTime(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.serverSideTime = b
+	})
+}
+
+// WithMetadataHash can be used to attach tags to the lock. While the lock is held, they are stored in the Redis hash
+// "<name>:meta" with the same TTL as the lock, so that other processes can see what the lock is held for. The hash is
+// deleted on unlock and its TTL follows extensions. Tags can be changed while the lock is held with Mutex.UpdateTags.
+//
+// The lock and its hash are accessed by the same scripts, so they must hash to the same slot: with Redis Cluster, or
+// with the rueidis driver, which refuses multi-key commands across slots, the name of the mutex must be a hash tag,
+// such as "{name}", or contain one.
+func WithMetadataHash(tags map[string]string) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.metadata = make(map[string]string, len(tags))
+		for k, v := range tags {
+			m.metadata[k] = v
+		}
+	})
+}
+
+// WithBuiltinHistogram can be used to record the latency of lock acquisitions in a fixed-size histogram, without any
+// external dependency. The counts are returned by Mutex.LatencyHistogram.
+func WithBuiltinHistogram(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		if b {
+			m.histogram = &latencyHistogram{}
+		} else {
+			m.histogram = nil
+		}
+	})
+}
+
+// WithSlowLockThreshold can be used to be alerted when acquiring the lock takes longer than threshold, which indicates
+// contention or Redis latency. alert is called at most once per call to Lock, inline from the goroutine calling Lock,
+// as soon as the threshold is found exceeded between attempts, so it must be fast.
+func WithSlowLockThreshold(threshold time.Duration, alert func(name string, elapsed time.Duration)) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.slowLockThreshold = threshold
+		m.slowLockAlert = alert
+	})
+}
+
+// WithHistory can be used to keep the last maxEvents lock, unlock, extend, expiry and retry events of the mutex in
+// memory, for debugging. They are returned by Mutex.History.
+func WithHistory(maxEvents int) Option {
+	return OptionFunc(func(m *Mutex) {
+		if maxEvents > 0 {
+			m.history = &lockHistory{events: make([]LockEvent, maxEvents)}
+		} else {
+			m.history = nil
+		}
+	})
+}
+
+// WithSafeRename can be used to make Mutex.MigrateKey move the lock with COPY and DEL rather than RENAME, so that an
+// existing key with the new name is never overwritten. COPY requires Redis 6.2 or later; on older servers, RENAME is
+// used and a warning is logged.
+func WithSafeRename(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.safeRename = b
+	})
+}
+
+// WithExtensionListener can be used to receive an ExtensionEvent on ch after each attempt to extend the lock. Events
+// are sent without blocking: they are dropped if ch is full.
+func WithExtensionListener(ch chan<- ExtensionEvent) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.extensionListener = ch
+	})
+}
+
+// WithPublishEvents can be used to publish a MutexEvent on Redis Pub/Sub each time the lock is acquired, extended or
+// released, so that locks can be monitored across a fleet with Redsync.Subscribe.
+func WithPublishEvents(b bool) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.publishEvents = b
+	})
+}
+
+// WithCapacityWarn can be used to be warned when Redis is near its memory limit, before lock acquisitions start to
+// fail. Before each attempt to acquire the lock, warn is called if the memory used by any of the pools, as a ratio
+// of its maxmemory, exceeds threshold. The usage is read with INFO memory and cached for 10s. Pools without a
+// maxmemory limit are ignored.
+func WithCapacityWarn(threshold float64, warn func(usedRatio float64)) Option {
+	return OptionFunc(func(m *Mutex) {
+		m.capacity = &capacityMonitor{threshold: threshold, warn: warn}
+	})
+}
+
 // randomPools shuffles Redis pools.
 func randomPools(pools []redis.Pool) {
 	rand.Shuffle(len(pools), func(i, j int) {
@@ -2,8 +2,13 @@ package redsync
 
 import (
 	"context"
+	"errors"
+	"math"
 	"os"
+	"reflect"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +20,7 @@ import (
 	goredis_v7 "github.com/go-redsync/redsync/v4/redis/goredis/v7"
 	goredis_v8 "github.com/go-redsync/redsync/v4/redis/goredis/v8"
 	goredis_v9 "github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	"github.com/go-redsync/redsync/v4/redis/memory"
 	"github.com/go-redsync/redsync/v4/redis/redigo"
 	rueidis "github.com/go-redsync/redsync/v4/redis/rueidis"
 	redigolib "github.com/gomodule/redigo/redis"
@@ -103,6 +109,113 @@ func TestRedsync(t *testing.T) {
 	}
 }
 
+func TestRedsyncPurge(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			prefix := k + "-test-purge:"
+
+			mutexes := rs.NewMutexPool(3, prefix+"lock", WithExpiry(time.Hour))
+			mutexes = append(mutexes, rs.NewMutex(prefix+"versioned", WithExpiry(time.Hour), WithVersionedLock(true)))
+			for _, mutex := range mutexes {
+				err := mutex.Lock()
+				if err != nil {
+					t.Fatalf("mutex lock failed: %s", err)
+				}
+			}
+			foreign := rs.NewMutex(prefix + "foreign")
+			clogPools(v.pools, 1<<len(v.pools)-1, foreign)
+			outside := rs.NewMutex(k+"-test-purge-outside", WithExpiry(time.Hour))
+			err := outside.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+
+			n, err := rs.Purge(ctx, prefix+"*")
+			if err != nil {
+				t.Fatalf("purge failed: %s", err)
+			}
+			if expected := len(mutexes) * len(v.pools); n != expected {
+				t.Fatalf("Expected %d deleted keys, got %d", expected, n)
+			}
+			for _, mutex := range mutexes {
+				if countAcquiredPools(ctx, v.pools, mutex) != 0 {
+					t.Fatalf("Expected %q to be purged", mutex.Name())
+				}
+			}
+			for _, value := range getPoolValues(ctx, v.pools, foreign.Name()) {
+				if value != "foobar" {
+					t.Fatalf("Expected foreign key to be kept, got %q", value)
+				}
+			}
+			assertAcquired(ctx, t, v.pools, outside)
+		})
+	}
+}
+
+func TestRedsyncNewMutexPool(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutexes := rs.NewMutexPool(3, "test-mutex-pool", WithTries(1))
+	if len(mutexes) != 3 {
+		t.Fatalf("Expected 3 mutexes, got %d", len(mutexes))
+	}
+	for i, mutex := range mutexes {
+		if name := "test-mutex-pool:" + strconv.Itoa(i); mutex.Name() != name {
+			t.Fatalf("Expected name %q, got %q", name, mutex.Name())
+		}
+		if mutex.tries != 1 {
+			t.Fatalf("Expected tries = 1, got %d", mutex.tries)
+		}
+		err := mutex.Lock()
+		if err != nil {
+			t.Fatalf("mutex lock failed: %s", err)
+		}
+	}
+}
+
+func TestRedsyncMemoryPool(t *testing.T) {
+	pools := []redis.Pool{memory.NewPool(), memory.NewPool(), memory.NewPool()}
+	rs := New(pools...)
+
+	mutex1 := rs.NewMutex("test-memory-pool")
+	err := mutex1.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+
+	mutex2 := rs.NewMutex("test-memory-pool")
+	err = mutex2.TryLock()
+	var errTaken *ErrTaken
+	if !errors.As(err, &errTaken) {
+		t.Fatalf("mutex was not already locked: %v", err)
+	}
+
+	ok, err := mutex1.Extend()
+	if err != nil || !ok {
+		t.Fatalf("mutex extend failed: %v", err)
+	}
+	ok, err = mutex1.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+
+	err = mutex2.TryLock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+
+	mutex3 := rs.NewMutex("test-memory-pool-ok", WithGenValueFunc(func() (string, error) { return "OK", nil }))
+	err = mutex3.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	ok, err = mutex3.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+}
+
 func newMockPoolsRedigo(n int) []redis.Pool {
 	pools := make([]redis.Pool, n)
 
@@ -201,3 +314,1212 @@ func newMockPoolsRueidis(n int) []redis.Pool {
 	}
 	return pools
 }
+
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) MutexCreated(name string) {
+	o.events = append(o.events, "created "+name)
+}
+
+func (o *recordingObserver) LockAcquired(name, value string) {
+	o.events = append(o.events, "acquired "+name)
+}
+
+func (o *recordingObserver) LockFailed(name string, err error) {
+	o.events = append(o.events, "failed "+name)
+}
+
+func (o *recordingObserver) LockReleased(name string) {
+	o.events = append(o.events, "released "+name)
+}
+
+func TestRedsyncWithObserver(t *testing.T) {
+	o1, o2 := &recordingObserver{}, &recordingObserver{}
+	rs := New(memory.NewPool()).WithObserver(MultiRedsyncObserver{o1, o2})
+
+	mutex1 := rs.NewMutex("test-observer", WithTries(1))
+	mutex2 := rs.NewMutex("test-observer", WithTries(1))
+	err := mutex1.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	err = mutex2.Lock()
+	if err == nil {
+		t.Fatalf("Expected error when locking a locked mutex")
+	}
+	_, err = mutex1.Unlock()
+	if err != nil {
+		t.Fatalf("mutex unlock failed: %s", err)
+	}
+
+	expected := []string{
+		"created test-observer",
+		"created test-observer",
+		"acquired test-observer",
+		"failed test-observer",
+		"released test-observer",
+	}
+	for _, o := range []*recordingObserver{o1, o2} {
+		if !reflect.DeepEqual(o.events, expected) {
+			t.Fatalf("Expected events %v, got %v", expected, o.events)
+		}
+	}
+}
+
+func TestMutexLockWithExpectedTTL(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-expected-ttl", WithExpiry(time.Second), WithTries(3), WithRetryDelay(time.Millisecond))
+
+	err := mutex.LockWithExpectedTTL(ctx, 2*time.Second)
+	var ttlErr *ErrInsufficientTTL
+	if !errors.As(err, &ttlErr) {
+		t.Fatalf("Expected *ErrInsufficientTTL, got %v", err)
+	}
+	if ttlErr.TTL <= 0 || ttlErr.TTL >= time.Second {
+		t.Fatalf("Expected achieved TTL in (0, 1s), got %s", ttlErr.TTL)
+	}
+	if ok, _ := mutex.Valid(); ok {
+		t.Fatalf("Expected lock to be released")
+	}
+
+	err = mutex.LockWithExpectedTTL(ctx, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if ok, _ := mutex.Valid(); !ok {
+		t.Fatalf("Expected lock to be held")
+	}
+}
+
+func TestRedsyncCounter(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool(), memory.NewPool(), memory.NewPool())
+	counter := rs.NewCounter("test-counter")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := counter.Increment(ctx)
+			if err != nil {
+				t.Errorf("counter increment failed: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, err := counter.Read(ctx)
+	if err != nil {
+		t.Fatalf("counter read failed: %s", err)
+	}
+	if n != 10 {
+		t.Fatalf("Expected counter = 10, got %d", n)
+	}
+
+	ok, err := counter.Reset(ctx, 9)
+	if err != nil || ok {
+		t.Fatalf("Expected reset with a stale value to fail, got %v, %v", ok, err)
+	}
+	ok, err = counter.Reset(ctx, 10)
+	if err != nil || !ok {
+		t.Fatalf("counter reset failed: %v, %v", ok, err)
+	}
+	if n, _ := counter.Read(ctx); n != 0 {
+		t.Fatalf("Expected counter = 0, got %d", n)
+	}
+}
+
+func TestRedsyncCounterResetDiverged(t *testing.T) {
+	ctx := context.Background()
+	pools := []redis.Pool{memory.NewPool(), memory.NewPool(), memory.NewPool()}
+	// The last pool missed some increments.
+	for i, value := range []string{"5", "5", "3"} {
+		conn, err := pools[i].Get(ctx)
+		if err != nil {
+			t.Fatalf("pool get failed: %s", err)
+		}
+		if _, err := conn.Set("test-counter-diverged", value); err != nil {
+			t.Fatalf("set failed: %s", err)
+		}
+		conn.Close()
+	}
+	counter := New(pools...).NewCounter("test-counter-diverged")
+
+	ok, err := counter.Reset(ctx, 3)
+	if err != nil || ok {
+		t.Fatalf("Expected reset with the value of a minority of pools to fail, got %v, %v", ok, err)
+	}
+	ok, err = counter.Reset(ctx, 5)
+	if err != nil || !ok {
+		t.Fatalf("counter reset failed: %v, %v", ok, err)
+	}
+	for i, pool := range pools {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			t.Fatalf("pool get failed: %s", err)
+		}
+		value, err := conn.Get("test-counter-diverged")
+		conn.Close()
+		if err != nil || value != "0" {
+			t.Fatalf("Expected the counter to be reset on pool %d, got %q, %v", i, value, err)
+		}
+	}
+}
+
+// scriptFailingPool fails the evaluations of a single script, leaving the other commands to its delegate.
+type scriptFailingPool struct {
+	redis.Pool
+	script *redis.Script
+}
+
+func (p scriptFailingPool) Get(ctx context.Context) (redis.Conn, error) {
+	conn, err := p.Pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return scriptFailingConn{conn, p.script}, nil
+}
+
+type scriptFailingConn struct {
+	redis.Conn
+	script *redis.Script
+}
+
+func (c scriptFailingConn) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	if script == c.script {
+		return nil, errors.New("ERR script failed")
+	}
+	return c.Conn.Eval(script, keysAndArgs...)
+}
+
+// getCounterValues returns the value of the counter name on each pool.
+func getCounterValues(ctx context.Context, t *testing.T, pools []redis.Pool, name string) []string {
+	values := make([]string, len(pools))
+	for i, pool := range pools {
+		conn, err := pool.Get(ctx)
+		if err != nil {
+			t.Fatalf("pool get failed: %s", err)
+		}
+		values[i], err = conn.Get(name)
+		conn.Close()
+		if err != nil {
+			t.Fatalf("get failed: %s", err)
+		}
+	}
+	return values
+}
+
+func TestRedsyncCounterIncrementRollback(t *testing.T) {
+	ctx := context.Background()
+	pools := []redis.Pool{
+		memory.NewPool(),
+		scriptFailingPool{memory.NewPool(), incrScript},
+		scriptFailingPool{memory.NewPool(), incrScript},
+	}
+	counter := New(pools...).NewCounter("test-counter-increment-rollback")
+
+	_, err := counter.Increment(ctx)
+	if err == nil {
+		t.Fatalf("Expected counter increment on a minority of pools to fail")
+	}
+	if values := getCounterValues(ctx, t, pools, "test-counter-increment-rollback"); values[0] != "0" {
+		t.Fatalf("Expected the partial increment to be rolled back, got %v", values)
+	}
+}
+
+func TestRedsyncCounterResetAhead(t *testing.T) {
+	ctx := context.Background()
+	// The last pool is ahead, but its value cannot be read.
+	pools := []redis.Pool{memory.NewPool(), memory.NewPool(), scriptFailingPool{memory.NewPool(), counterGetScript}}
+	for i, value := range []string{"5", "5", "7"} {
+		conn, err := pools[i].Get(ctx)
+		if err != nil {
+			t.Fatalf("pool get failed: %s", err)
+		}
+		if _, err := conn.Set("test-counter-ahead", value); err != nil {
+			t.Fatalf("set failed: %s", err)
+		}
+		conn.Close()
+	}
+	counter := New(pools...).NewCounter("test-counter-ahead")
+
+	ok, err := counter.Reset(ctx, 5)
+	if err != nil || ok {
+		t.Fatalf("Expected reset of a pool ahead of the expected value to fail, got %v, %v", ok, err)
+	}
+	if values := getCounterValues(ctx, t, pools, "test-counter-ahead"); !reflect.DeepEqual(values, []string{"5", "5", "7"}) {
+		t.Fatalf("Expected the partial reset to be rolled back, got %v", values)
+	}
+}
+
+func TestMutexLatencyHistogram(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-latency-histogram", WithBuiltinHistogram(true))
+	if h := rs.NewMutex("test-latency-histogram").LatencyHistogram(); h != nil {
+		t.Fatalf("Expected no histogram without WithBuiltinHistogram, got %v", h)
+	}
+
+	for i := 0; i < 3; i++ {
+		err := mutex.Lock()
+		if err != nil {
+			t.Fatalf("mutex lock failed: %s", err)
+		}
+		_, err = mutex.Unlock()
+		if err != nil {
+			t.Fatalf("mutex unlock failed: %s", err)
+		}
+	}
+
+	h := mutex.LatencyHistogram()
+	if len(h) != 11 {
+		t.Fatalf("Expected 11 buckets, got %d", len(h))
+	}
+	if h[0].UpperBound != time.Millisecond || h[10].UpperBound != math.MaxInt64 {
+		t.Fatalf("Unexpected bucket bounds %v", h)
+	}
+	var total uint64
+	for _, b := range h {
+		total += b.Count
+	}
+	if total != 3 {
+		t.Fatalf("Expected 3 observations, got %d", total)
+	}
+}
+
+func TestShardedRedsync(t *testing.T) {
+	shards := []*Redsync{New(memory.NewPool()), New(memory.NewPool()), New(memory.NewPool())}
+	if _, err := NewSharded(); !errors.Is(err, ErrNoShards) {
+		t.Fatalf("Expected %q, got %v", ErrNoShards, err)
+	}
+	s, err := NewSharded(shards...)
+	if err != nil {
+		t.Fatalf("new sharded failed: %s", err)
+	}
+	if s.NumShards() != 3 {
+		t.Fatalf("Expected 3 shards, got %d", s.NumShards())
+	}
+
+	used := map[*Redsync]bool{}
+	for i := 0; i < 32; i++ {
+		name := "test-sharded-" + strconv.Itoa(i)
+		shard := s.Shard(name)
+		if s.Shard(name) != shard {
+			t.Fatalf("Expected %q to map to the same shard", name)
+		}
+		used[shard] = true
+
+		mutex := s.NewMutex(name)
+		err := mutex.Lock()
+		if err != nil {
+			t.Fatalf("mutex lock failed: %s", err)
+		}
+		conn, _ := shard.pools[0].Get(context.Background())
+		value, _ := conn.Get(name)
+		if value != mutex.Value() {
+			t.Fatalf("Expected %q to be locked on its shard", name)
+		}
+	}
+	if len(used) != 3 {
+		t.Fatalf("Expected names to be spread over 3 shards, got %d", len(used))
+	}
+}
+
+func TestRedsyncUnixSocket(t *testing.T) {
+	offset := GoredisV9Block * ServerPoolSize
+	pools := make([]redis.Pool, 3)
+	for i := range pools {
+		pools[i] = goredis_v9.NewPoolFromSocket(servers[i+offset].Socket(), func(o *goredislib_v9.Options) {
+			o.PoolSize = 2
+		})
+	}
+	rs := New(pools...)
+	mutex := rs.NewMutex("test-unix-socket")
+
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	assertAcquired(context.Background(), t, pools, mutex)
+
+	ok, err := mutex.Extend()
+	if err != nil || !ok {
+		t.Fatalf("mutex extend failed: %v", err)
+	}
+	ok, err = mutex.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+	for i, value := range getPoolValues(context.Background(), pools, mutex.name) {
+		if value != "" {
+			t.Fatalf("Expected lock to be released on pool %d, got %q", i, value)
+		}
+	}
+}
+
+func TestMutexUnlockAndPublish(t *testing.T) {
+	ctx := context.Background()
+	offset := GoredisV9Block * ServerPoolSize
+	pools := newMockPoolsGoredisV9(3)
+	subs := make([]*goredislib_v9.PubSub, len(pools))
+	for i := range pools {
+		client := goredislib_v9.NewClient(&goredislib_v9.Options{
+			Network: "unix",
+			Addr:    servers[i+offset].Socket(),
+		})
+		defer client.Close()
+		subs[i] = client.Subscribe(ctx, "test-unlock-and-publish")
+		defer subs[i].Close()
+		if _, err := subs[i].Receive(ctx); err != nil {
+			t.Fatalf("subscribe failed: %s", err)
+		}
+	}
+
+	rs := New(pools...)
+	mutex := rs.NewMutex("test-unlock-and-publish")
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	err = mutex.UnlockAndPublish(ctx, "test-unlock-and-publish", "released")
+	if err != nil {
+		t.Fatalf("mutex unlock and publish failed: %s", err)
+	}
+	for i, value := range getPoolValues(ctx, pools, mutex.name) {
+		if value != "" {
+			t.Fatalf("Expected lock to be released on pool %d, got %q", i, value)
+		}
+	}
+	for i, sub := range subs {
+		select {
+		case msg := <-sub.Channel():
+			if msg.Payload != "released" {
+				t.Fatalf("Expected message %q on pool %d, got %q", "released", i, msg.Payload)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a message on pool %d", i)
+		}
+	}
+
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	other := rs.NewMutex("test-unlock-and-publish", WithValue("other"))
+	err = other.UnlockAndPublish(ctx, "test-unlock-and-publish", "released")
+	var errTaken *ErrTaken
+	if !errors.As(err, &errTaken) {
+		t.Fatalf("Expected err to be an *ErrTaken, got %q", err)
+	}
+}
+
+func TestNewMutexFromEnv(t *testing.T) {
+	prev := DefaultRedsync
+	defer func() { DefaultRedsync = prev }()
+
+	DefaultRedsync = nil
+	if _, err := NewMutexFromEnv("test-env"); err != ErrNoDefaultRedsync {
+		t.Fatalf("Expected err == %q, got %q", ErrNoDefaultRedsync, err)
+	}
+
+	DefaultRedsync = New(memory.NewPool())
+	t.Setenv("REDSYNC_EXPIRY", "3s")
+	t.Setenv("REDSYNC_TRIES", "5")
+	t.Setenv("REDSYNC_RETRY_DELAY", "20ms")
+	t.Setenv("REDSYNC_FAIL_FAST", "true")
+	t.Setenv("REDSYNC_DRIFT_FACTOR", "not-a-number")
+	mutex, err := NewMutexFromEnv("test-env")
+	if err != nil {
+		t.Fatalf("new mutex from env failed: %s", err)
+	}
+	if mutex.getExpiry() != 3*time.Second || mutex.tries != 5 || mutex.delayFunc(1) != 20*time.Millisecond || !mutex.failFast {
+		t.Fatalf("Expected the options from the environment, got expiry %s, tries %d, delay %s, fail fast %t",
+			mutex.getExpiry(), mutex.tries, mutex.delayFunc(1), mutex.failFast)
+	}
+	if mutex.driftFactor != 0.01 {
+		t.Fatalf("Expected the default drift factor for an invalid value, got %v", mutex.driftFactor)
+	}
+
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+}
+
+// noScriptingPool emulates a Redis server older than 2.6, which does not know EVAL and EVALSHA.
+type noScriptingPool struct {
+	redis.Pool
+}
+
+func (p noScriptingPool) Get(ctx context.Context) (redis.Conn, error) {
+	conn, err := p.Pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return noScriptingConn{conn}, nil
+}
+
+type noScriptingConn struct {
+	redis.Conn
+}
+
+func (noScriptingConn) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	return nil, errors.New("ERR unknown command 'EVALSHA'")
+}
+
+type unreachablePool struct{}
+
+func (unreachablePool) Get(ctx context.Context) (redis.Conn, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestRedsyncValidate(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(3) {
+		t.Run(k, func(t *testing.T) {
+			err := New(v.pools...).Validate(ctx)
+			if err != nil {
+				t.Fatalf("validate failed: %s", err)
+			}
+
+			pools := append(append([]redis.Pool(nil), v.pools...), unreachablePool{}, unreachablePool{})
+			err = New(pools...).Validate(ctx)
+			var errs ValidationErrors
+			if !errors.As(err, &errs) || len(errs) != 2 {
+				t.Fatalf("Expected 2 validation errors, got %v", err)
+			}
+			for _, err := range errs {
+				var redisErr *RedisError
+				if !errors.As(err, &redisErr) || redisErr.Node < 3 {
+					t.Fatalf("Expected errors for the unreachable pools, got %q", err)
+				}
+			}
+
+			pools = append(pools, unreachablePool{}, unreachablePool{})
+			err = New(pools...).Validate(ctx)
+			if !errors.As(err, &errs) || len(errs) != 5 {
+				t.Fatalf("Expected 4 pool errors and a quorum error, got %v", err)
+			}
+
+			err = New(append(v.pools[:2:2], noScriptingPool{v.pools[2]})...).Validate(ctx)
+			if !errors.Is(err, ErrScriptingUnsupported) {
+				t.Fatalf("Expected err == %q, got %v", ErrScriptingUnsupported, err)
+			}
+		})
+	}
+}
+
+func TestLastWriteWinsMutex(t *testing.T) {
+	for k, v := range makeCases(3) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			first := rs.NewLastWriteWinsMutex(k + "-test-last-write-wins")
+			second := rs.NewLastWriteWinsMutex(k + "-test-last-write-wins")
+
+			err := first.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			err = second.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			if ok, err := first.IsOwner(); err != nil || ok {
+				t.Fatalf("Expected the first mutex to be superseded, got %v, %v", ok, err)
+			}
+			if ok, err := second.IsOwner(); err != nil || !ok {
+				t.Fatalf("Expected the second mutex to own the lock, got %v, %v", ok, err)
+			}
+
+			if ok, _ := first.Unlock(); ok {
+				t.Fatalf("Expected the superseded mutex not to release the lock")
+			}
+			ok, err := second.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+			if ok, err := second.IsOwner(); err != nil || ok {
+				t.Fatalf("Expected the lock to be released, got %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestMutexLockQuietly(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-lock-quietly")
+	acquired, err := mutex.LockQuietly(ctx)
+	if err != nil || !acquired {
+		t.Fatalf("mutex lock quietly failed: %v", err)
+	}
+
+	other := rs.NewMutex("test-lock-quietly", WithTries(2), WithRetryDelay(time.Millisecond))
+	acquired, err = other.LockQuietly(ctx)
+	if err != nil || acquired {
+		t.Fatalf("Expected the lock not to be acquired without error, got %v, %v", acquired, err)
+	}
+
+	unreachable := New(unreachablePool{}).NewMutex("test-lock-quietly", WithTries(1))
+	acquired, err = unreachable.LockQuietly(ctx)
+	var redisErr *RedisError
+	if acquired || !errors.As(err, &redisErr) {
+		t.Fatalf("Expected a Redis error, got %v, %v", acquired, err)
+	}
+}
+
+func TestMutexExtensionListener(t *testing.T) {
+	events := make(chan ExtensionEvent, 2)
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-extension-listener", WithExtensionListener(events))
+
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	ok, err := mutex.Extend()
+	if err != nil || !ok {
+		t.Fatalf("mutex extend failed: %v", err)
+	}
+	ok, err = mutex.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+	if ok, _ := mutex.Extend(); ok {
+		t.Fatalf("Expected extend of a released lock to fail")
+	}
+	// The channel is full: the event is dropped.
+	_, _ = mutex.Extend()
+
+	event := <-events
+	if event.Attempt != 1 || !event.Success || event.Error != nil || event.RemainingTTL <= 0 || event.Timestamp.IsZero() {
+		t.Fatalf("Expected a successful first attempt, got %+v", event)
+	}
+	event = <-events
+	if event.Attempt != 2 || event.Success || event.Error == nil {
+		t.Fatalf("Expected a failed second attempt, got %+v", event)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("Expected the event sent to a full channel to be dropped, got %+v", event)
+	default:
+	}
+
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	_, _ = mutex.Extend()
+	if event := <-events; event.Attempt != 1 {
+		t.Fatalf("Expected attempts to restart at 1 after locking, got %d", event.Attempt)
+	}
+}
+
+func TestRedsyncSubscribe(t *testing.T) {
+	for k, v := range makeCases(3) {
+		t.Run(k, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			rs := New(v.pools...)
+			events, err := rs.Subscribe(ctx, k+"-test-subscribe*")
+			if k == "rueidis" {
+				if err != ErrSubscribeUnsupported {
+					t.Fatalf("Expected err == %q, got %q", ErrSubscribeUnsupported, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("subscribe failed: %s", err)
+			}
+
+			_ = rs.NewMutex(k+"-other", WithPublishEvents(true)).Lock()
+			mutex := rs.NewMutex(k+"-test-subscribe", WithPublishEvents(true))
+			err = mutex.Lock()
+			if err != nil {
+				t.Fatalf("mutex lock failed: %s", err)
+			}
+			value := mutex.Value()
+			if ok, err := mutex.Extend(); err != nil || !ok {
+				t.Fatalf("mutex extend failed: %v", err)
+			}
+			if ok, err := mutex.Unlock(); err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+
+			for _, typ := range []LockEventType{EventLock, EventExtend, EventUnlock} {
+				select {
+				case event := <-events:
+					if event.Type != typ || event.Name != mutex.Name() || event.Value != value || event.Owner == "" {
+						t.Fatalf("Expected a %s event for the mutex, got %+v", typ, event)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("Expected a %s event", typ)
+				}
+			}
+			select {
+			case event := <-events:
+				t.Fatalf("Expected each event to be delivered once, got %+v", event)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			cancel()
+			select {
+			case _, ok := <-events:
+				if ok {
+					t.Fatalf("Expected no more events")
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Expected the channel to be closed once the context is done")
+			}
+		})
+	}
+}
+
+type memoryInfoPool struct {
+	redis.Pool
+	info    string
+	queries int32
+}
+
+func (p *memoryInfoPool) Get(ctx context.Context) (redis.Conn, error) {
+	conn, err := p.Pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryInfoConn{conn, p}, nil
+}
+
+type memoryInfoConn struct {
+	redis.Conn
+	pool *memoryInfoPool
+}
+
+func (c *memoryInfoConn) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	if script == infoMemoryScript {
+		atomic.AddInt32(&c.pool.queries, 1)
+		return c.pool.info, nil
+	}
+	return c.Conn.Eval(script, keysAndArgs...)
+}
+
+func TestMutexCapacityWarn(t *testing.T) {
+	full := &memoryInfoPool{Pool: memory.NewPool(), info: "# Memory\r\nused_memory:950\r\nmaxmemory:1000\r\n"}
+	unlimited := &memoryInfoPool{Pool: memory.NewPool(), info: "# Memory\r\nused_memory:950\r\nmaxmemory:0\r\n"}
+	rs := New(full, unlimited)
+
+	var warnings []float64
+	mutex := rs.NewMutex("test-capacity-warn", WithCapacityWarn(0.9, func(usedRatio float64) {
+		warnings = append(warnings, usedRatio)
+	}))
+	for i := 0; i < 2; i++ {
+		if err := mutex.Lock(); err != nil {
+			t.Fatalf("mutex lock failed: %s", err)
+		}
+		if ok, err := mutex.Unlock(); err != nil || !ok {
+			t.Fatalf("mutex unlock failed: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(warnings, []float64{0.95, 0.95}) {
+		t.Fatalf("Expected a warning for each lock, got %v", warnings)
+	}
+	if queries := atomic.LoadInt32(&full.queries); queries != 1 {
+		t.Fatalf("Expected the memory usage to be cached, got %d queries", queries)
+	}
+
+	relaxed := rs.NewMutex("test-capacity-warn", WithCapacityWarn(0.99, func(usedRatio float64) {
+		t.Errorf("Expected no warning below the threshold, got %v", usedRatio)
+	}))
+	if err := relaxed.Lock(); err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+}
+
+func TestMutexSlowLockThreshold(t *testing.T) {
+	rs := New(memory.NewPool())
+	var alerts []time.Duration
+	alert := func(name string, elapsed time.Duration) {
+		if name != "test-slow-lock" {
+			t.Errorf("Expected name %q, got %q", "test-slow-lock", name)
+		}
+		alerts = append(alerts, elapsed)
+	}
+
+	mutex1 := rs.NewMutex("test-slow-lock", WithExpiry(200*time.Millisecond), WithSlowLockThreshold(50*time.Millisecond, alert))
+	err := mutex1.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("Expected no alert for a fast lock, got %v", alerts)
+	}
+
+	mutex2 := rs.NewMutex("test-slow-lock", WithRetryDelay(10*time.Millisecond), WithSlowLockThreshold(50*time.Millisecond, alert))
+	err = mutex2.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if len(alerts) != 1 || alerts[0] <= 50*time.Millisecond {
+		t.Fatalf("Expected a single alert after 50ms, got %v", alerts)
+	}
+}
+
+func TestRedsyncTryLockAll(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool())
+	names := []string{"test-try-lock-all-a", "test-try-lock-all-b", "test-try-lock-all-c"}
+
+	mutexes, err := rs.TryLockAll(ctx, names)
+	if err != nil {
+		t.Fatalf("try lock all failed: %s", err)
+	}
+	for i, mutex := range mutexes {
+		if mutex.Name() != names[i] {
+			t.Fatalf("Expected mutex %q, got %q", names[i], mutex.Name())
+		}
+	}
+	_, err = mutexes[0].Unlock()
+	if err != nil {
+		t.Fatalf("mutex unlock failed: %s", err)
+	}
+	_, err = mutexes[2].Unlock()
+	if err != nil {
+		t.Fatalf("mutex unlock failed: %s", err)
+	}
+
+	_, err = rs.TryLockAll(ctx, names)
+	var partial *ErrPartialLock
+	if !errors.As(err, &partial) {
+		t.Fatalf("Expected *ErrPartialLock, got %v", err)
+	}
+	if !reflect.DeepEqual(partial.Available, []string{names[0], names[2]}) || !reflect.DeepEqual(partial.Unavailable, []string{names[1]}) {
+		t.Fatalf("Unexpected partial lock %+v", partial)
+	}
+	err = rs.NewMutex(names[0]).TryLock()
+	if err != nil {
+		t.Fatalf("Expected acquired locks to be released, got %s", err)
+	}
+}
+
+func TestMutexHistory(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex1 := rs.NewMutex("test-history", WithHistory(3))
+	if h := rs.NewMutex("test-history").History(); h != nil {
+		t.Fatalf("Expected no history without WithHistory, got %v", h)
+	}
+
+	err := mutex1.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	_, err = mutex1.Extend()
+	if err != nil {
+		t.Fatalf("mutex extend failed: %s", err)
+	}
+	_, err = mutex1.Unlock()
+	if err != nil {
+		t.Fatalf("mutex unlock failed: %s", err)
+	}
+	err = mutex1.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	assertHistory(t, mutex1.History(), EventExtend, EventUnlock, EventLock)
+
+	mutex2 := rs.NewMutex("test-history", WithHistory(3), WithTries(2), WithRetryDelay(time.Millisecond))
+	err = mutex2.Lock()
+	if err == nil {
+		t.Fatalf("Expected error when locking a locked mutex")
+	}
+	history := mutex2.History()
+	assertHistory(t, history, EventRetry, EventLock)
+	if history[1].Attempt != 2 || history[1].Err == nil {
+		t.Fatalf("Expected failed lock after 2 attempts, got %+v", history[1])
+	}
+}
+
+func assertHistory(t *testing.T, history []LockEvent, expected ...LockEventType) {
+	t.Helper()
+	var types []LockEventType
+	for _, event := range history {
+		types = append(types, event.Type)
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("Expected events %v, got %v", expected, types)
+	}
+}
+
+func TestMutexWaitForExtend(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-wait-for-extend")
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+
+	ok, err := mutex.WaitForExtend(ctx, 50*time.Millisecond)
+	if err != nil || ok {
+		t.Fatalf("Expected timeout, got %v, %v", ok, err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = mutex.Extend()
+	}()
+	ok, err = mutex.WaitForExtend(ctx, time.Second)
+	if err != nil || !ok {
+		t.Fatalf("Expected extend to be observed, got %v, %v", ok, err)
+	}
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	ok, err = mutex.WaitForExtend(cancelCtx, time.Second)
+	if ok || err != context.Canceled {
+		t.Fatalf("Expected context error, got %v, %v", ok, err)
+	}
+}
+
+// stallingPool stalls the first stalls calls to Get until their context is done.
+type stallingPool struct {
+	redis.Pool
+	stalls int32
+}
+
+func (p *stallingPool) Get(ctx context.Context) (redis.Conn, error) {
+	if atomic.AddInt32(&p.stalls, -1) >= 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return p.Pool.Get(ctx)
+}
+
+func TestMutexLockWithTimeout(t *testing.T) {
+	ctx := context.Background()
+	pool := &stallingPool{Pool: memory.NewPool(), stalls: 1}
+	rs := New(pool)
+	mutex := rs.NewMutex("test-lock-with-timeout", WithExpiry(time.Minute), WithRetryDelay(time.Millisecond))
+
+	start := time.Now()
+	err := mutex.LockWithTimeout(ctx, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("Expected the first attempt to time out after 20ms, took %s", elapsed)
+	}
+}
+
+func TestMutexLockToggle(t *testing.T) {
+	rs := New(memory.NewPool())
+	disabled := WithLockToggle(func(ctx context.Context) bool { return false })
+
+	mutex1 := rs.NewMutex("test-lock-toggle", disabled)
+	mutex2 := rs.NewMutex("test-lock-toggle", disabled, WithTries(1))
+	err := mutex1.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	err = mutex2.Lock()
+	if err != nil {
+		t.Fatalf("Expected no-op lock to succeed, got %s", err)
+	}
+	ok, err := mutex1.Extend()
+	if err != nil || !ok {
+		t.Fatalf("mutex extend failed: %v", err)
+	}
+	ok, err = mutex1.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+}
+
+func TestMutexLockToggleReleaseAfter(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-lock-toggle-release-after", WithLockToggle(func(ctx context.Context) bool { return false }))
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	err = mutex.ReleaseAfter(context.Background(), 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("mutex release after failed: %s", err)
+	}
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !mutex.isBypassed() {
+		t.Fatalf("Expected the delayed unlock to be cancelled by Lock")
+	}
+}
+
+func TestMutexMetrics(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex1 := rs.NewMutex("test-metrics")
+	mutex2 := rs.NewMutex("test-metrics", WithTries(1))
+
+	err := mutex1.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	_, err = mutex1.Extend()
+	if err != nil {
+		t.Fatalf("mutex extend failed: %s", err)
+	}
+	_ = mutex2.Lock()
+	_, _ = mutex2.Extend()
+	_, err = mutex1.Unlock()
+	if err != nil {
+		t.Fatalf("mutex unlock failed: %s", err)
+	}
+
+	metrics := mutex1.Metrics()
+	if metrics.TotalLocks != 1 || metrics.TotalExtends != 1 || metrics.TotalUnlocks != 1 || metrics.FailedLocks != 0 {
+		t.Fatalf("Unexpected metrics %+v", metrics)
+	}
+	if metrics.AvgAcquisitionLatencyNs <= 0 || metrics.P99AcquisitionLatencyNs != metrics.AvgAcquisitionLatencyNs {
+		t.Fatalf("Unexpected latencies %+v", metrics)
+	}
+	metrics = mutex2.Metrics()
+	if metrics.TotalLocks != 0 || metrics.FailedLocks != 1 || metrics.FailedExtends != 1 {
+		t.Fatalf("Unexpected metrics %+v", metrics)
+	}
+}
+
+func TestMutexSpinLock(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool())
+	mutex1 := rs.NewMutex("test-spin-lock")
+	mutex2 := rs.NewMutex("test-spin-lock", WithRetryDelay(time.Millisecond))
+
+	err := mutex1.SpinLock(ctx, 100*time.Microsecond)
+	if err != nil {
+		t.Fatalf("mutex spin lock failed: %s", err)
+	}
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		_, _ = mutex1.Unlock()
+	}()
+	err = mutex2.SpinLock(ctx, 100*time.Microsecond)
+	if err != nil {
+		t.Fatalf("Expected spin lock to fall back to Lock, got %s", err)
+	}
+}
+
+func TestMutexReleaseAfter(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-release-after")
+	err := mutex.ReleaseAfter(ctx, time.Millisecond)
+	if err != ErrLockNotHeld {
+		t.Fatalf("Expected err == %q, got %v", ErrLockNotHeld, err)
+	}
+
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	err = mutex.ReleaseAfter(ctx, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("mutex release after failed: %s", err)
+	}
+	other := rs.NewMutex("test-release-after", WithRetryDelay(10*time.Millisecond))
+	start := time.Now()
+	err = other.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("Expected lock to be held for 50ms, released after %s", elapsed)
+	}
+
+	err = other.ReleaseAfter(ctx, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("mutex release after failed: %s", err)
+	}
+	ok, err := other.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+	err = other.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if ok, _ := other.Valid(); !ok {
+		t.Fatalf("Expected the delayed unlock to be cancelled by Unlock")
+	}
+}
+
+func TestMutexReleaseAfterConcurrentExtend(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-release-after-extend")
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	err = mutex.ReleaseAfter(context.Background(), 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("mutex release after failed: %s", err)
+	}
+	// The delayed unlock races with the extensions: either may win, but the lock state is never corrupted.
+	for deadline := time.Now().Add(20 * time.Millisecond); time.Now().Before(deadline); {
+		_, _ = mutex.Extend()
+		_, _ = mutex.IncrementalExtend(context.Background(), time.Millisecond)
+	}
+	if ok, _ := mutex.Valid(); ok {
+		t.Fatalf("Expected the lock to be released")
+	}
+}
+
+func TestMutexReleaseAfterRelock(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-release-after-relock", WithExpiry(100*time.Millisecond), WithRetryDelay(10*time.Millisecond))
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	err = mutex.ReleaseAfter(ctx, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("mutex release after failed: %s", err)
+	}
+
+	// The lock expires before the delayed unlock, and is locked again.
+	time.Sleep(150 * time.Millisecond)
+	mutex.SetExpiry(time.Second)
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if ok, err := mutex.Valid(); !ok || err != nil {
+		t.Fatalf("Expected the delayed unlock not to release the new lock, got %v, %v", ok, err)
+	}
+	err = rs.NewMutex("test-release-after-relock", WithTries(1)).TryLock()
+	if err == nil {
+		t.Fatalf("Expected the new lock to be held")
+	}
+
+	// The delayed unlock of a lock that was unlocked is rejected.
+	ok, err := mutex.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+	err = mutex.ReleaseAfter(ctx, time.Millisecond)
+	if err != ErrLockNotHeld {
+		t.Fatalf("Expected err == %q, got %v", ErrLockNotHeld, err)
+	}
+}
+
+// orderedPool delays the lock attempts on its pool and records when they start and end in a log shared with other
+// pools.
+type orderedPool struct {
+	redis.Pool
+	node  int
+	delay time.Duration
+	log   *attemptLog
+}
+
+type attemptLog struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *attemptLog) add(entry string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (p *orderedPool) Get(ctx context.Context) (redis.Conn, error) {
+	conn, err := p.Pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &orderedConn{conn, p}, nil
+}
+
+type orderedConn struct {
+	redis.Conn
+	pool *orderedPool
+}
+
+func (c *orderedConn) SetNX(name string, value string, expiry time.Duration) (bool, error) {
+	c.pool.log.add("start " + strconv.Itoa(c.pool.node))
+	defer c.pool.log.add("end " + strconv.Itoa(c.pool.node))
+	time.Sleep(c.pool.delay)
+	return c.Conn.SetNX(name, value, expiry)
+}
+
+func TestMutexStickyPoolFirst(t *testing.T) {
+	log := &attemptLog{}
+	pools := make([]redis.Pool, 4)
+	for i := range pools {
+		delay := 20 * time.Millisecond
+		if i == 2 {
+			delay = 0
+		}
+		pools[i] = &orderedPool{Pool: memory.NewPool(), node: i, delay: delay, log: log}
+	}
+	mutex := New(pools...).NewMutex("test-sticky-pool-first", WithStickyPool(true))
+
+	for i := 0; i < 2; i++ {
+		log.entries = nil
+		err := mutex.Lock()
+		if err != nil {
+			t.Fatalf("mutex lock failed: %s", err)
+		}
+		ok, err := mutex.Unlock()
+		if err != nil || !ok {
+			t.Fatalf("mutex unlock failed: %v", err)
+		}
+	}
+	// The second lock attempt waited for the pool that granted the first lock before dispatching to the others.
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if len(log.entries) != 8 || log.entries[0] != "start 2" || log.entries[1] != "end 2" {
+		t.Fatalf("Expected the lock to be attempted on pool 2 first, got %v", log.entries)
+	}
+}
+
+func TestMutexStickyPoolHung(t *testing.T) {
+	pools := []redis.Pool{memory.NewPool(), memory.NewPool(), &stallingPool{Pool: memory.NewPool(), stalls: 1 << 20}}
+	rs := New(pools...)
+	mutex := rs.NewMutex("test-sticky-pool-hung", WithExpiry(2*time.Second), WithStickyPool(true), WithTries(1))
+
+	// The preferred pool hangs: the others are dispatched to after its head start.
+	mutex.stickyNode = 2
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if mutex.stickyNode == 2 {
+		t.Fatalf("Expected a healthy pool to become preferred")
+	}
+	// The hung pool stalls until the context of the unlock is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ok, err := mutex.UnlockContext(ctx)
+	if !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+
+	// A failed attempt forgets the preferred pool.
+	mutex.stickyNode = 2
+	err = rs.NewMutex("test-sticky-pool-hung", WithExpiry(2*time.Second), WithTries(1)).Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	err = mutex.Lock()
+	if err == nil {
+		t.Fatalf("Expected lock of a held lock to fail")
+	}
+	if mutex.stickyNode != -1 {
+		t.Fatalf("Expected the preferred pool to be forgotten, got %d", mutex.stickyNode)
+	}
+}
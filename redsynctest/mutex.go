@@ -0,0 +1,119 @@
+package redsynctest
+
+import (
+	"context"
+
+	"github.com/go-redsync/redsync/v4"
+)
+
+// A TestMutex is an in-process test double for *redsync.Mutex, for unit tests of code that uses a redsync.Locker. It
+// needs no Redis server: a TestMutex that is not held can always be locked immediately. Like a *redsync.Mutex, it can
+// only be held once at a time, including by the same goroutine.
+type TestMutex struct {
+	name string
+	sem  chan struct{}
+}
+
+var _ redsync.Locker = (*TestMutex)(nil)
+
+// NewTestMutex returns a new unlocked test mutex with given name.
+func NewTestMutex(name string) *TestMutex {
+	return &TestMutex{
+		name: name,
+		sem:  make(chan struct{}, 1),
+	}
+}
+
+// Name returns the mutex name.
+func (m *TestMutex) Name() string {
+	return m.name
+}
+
+// Lock locks m, waiting for it to be unlocked if it is held.
+func (m *TestMutex) Lock() error {
+	return m.LockContext(context.Background())
+}
+
+// LockContext locks m, waiting for it to be unlocked if it is held. It returns redsync.ErrFailed if ctx is done first.
+func (m *TestMutex) LockContext(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ctx.Err() != nil {
+		return redsync.ErrFailed
+	}
+	select {
+	case m.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return redsync.ErrFailed
+	}
+}
+
+// TryLock locks m if it is not held and returns redsync.ErrFailed otherwise.
+func (m *TestMutex) TryLock() error {
+	return m.TryLockContext(context.Background())
+}
+
+// TryLockContext locks m if it is not held and returns redsync.ErrFailed otherwise, or if ctx is done.
+func (m *TestMutex) TryLockContext(ctx context.Context) error {
+	if ctx != nil && ctx.Err() != nil {
+		return redsync.ErrFailed
+	}
+	select {
+	case m.sem <- struct{}{}:
+		return nil
+	default:
+		return redsync.ErrFailed
+	}
+}
+
+// Unlock unlocks m and returns the status of unlock.
+func (m *TestMutex) Unlock() (bool, error) {
+	return m.UnlockContext(context.Background())
+}
+
+// UnlockContext unlocks m and returns the status of unlock. It returns redsync.ErrLockAlreadyExpired if m is not held,
+// and the error of ctx if it is done.
+func (m *TestMutex) UnlockContext(ctx context.Context) (bool, error) {
+	if ctx != nil && ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	select {
+	case <-m.sem:
+		return true, nil
+	default:
+		return false, redsync.ErrLockAlreadyExpired
+	}
+}
+
+// Extend returns whether m is held, as a test mutex does not expire.
+func (m *TestMutex) Extend() (bool, error) {
+	return m.ExtendContext(context.Background())
+}
+
+// ExtendContext returns whether m is held, as a test mutex does not expire. It returns redsync.ErrExtendFailed if m
+// is not held, and the error of ctx if it is done.
+func (m *TestMutex) ExtendContext(ctx context.Context) (bool, error) {
+	if ctx != nil && ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if len(m.sem) == 0 {
+		return false, redsync.ErrExtendFailed
+	}
+	return true, nil
+}
+
+// MustLock is like LockContext but panics if m cannot be locked.
+func (m *TestMutex) MustLock(ctx context.Context) {
+	if err := m.LockContext(ctx); err != nil {
+		panic(err)
+	}
+}
+
+// MustUnlock is like UnlockContext but panics if m cannot be unlocked.
+func (m *TestMutex) MustUnlock(ctx context.Context) {
+	if _, err := m.UnlockContext(ctx); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,55 @@
+package redsynctest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+)
+
+func TestTestMutex(t *testing.T) {
+	ctx := context.Background()
+	var mutex redsync.Locker = NewTestMutex("test-mutex")
+	err := mutex.TryLock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	if err := mutex.TryLock(); err != redsync.ErrFailed {
+		t.Fatalf("Expected err == %q, got %q", redsync.ErrFailed, err)
+	}
+	ok, err := mutex.Extend()
+	if err != nil || !ok {
+		t.Fatalf("mutex extend failed: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := mutex.LockContext(timeoutCtx); err != redsync.ErrFailed {
+		t.Fatalf("Expected err == %q, got %q", redsync.ErrFailed, err)
+	}
+
+	ok, err = mutex.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+	if _, err := mutex.Unlock(); err != redsync.ErrLockAlreadyExpired {
+		t.Fatalf("Expected err == %q, got %q", redsync.ErrLockAlreadyExpired, err)
+	}
+	if _, err := mutex.Extend(); err != redsync.ErrExtendFailed {
+		t.Fatalf("Expected err == %q, got %q", redsync.ErrExtendFailed, err)
+	}
+}
+
+func TestTestMutexMustUnlock(t *testing.T) {
+	mutex := NewTestMutex("test-mutex-must")
+	mutex.MustLock(context.Background())
+	mutex.MustUnlock(context.Background())
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected MustUnlock to panic on an unlocked mutex")
+		}
+	}()
+	mutex.MustUnlock(context.Background())
+}
@@ -0,0 +1,35 @@
+// Package redsynctest provides utilities for testing code that uses redsync.
+package redsynctest
+
+import (
+	"testing"
+
+	"github.com/go-redsync/redsync/v4"
+)
+
+// AssertSnapshotEqual reports an error through t if the snapshots differ. TTLs are not compared, as they decrease
+// between the time snapshots are taken.
+func AssertSnapshotEqual(t testing.TB, expected, actual redsync.MutexSnapshot) {
+	t.Helper()
+
+	if expected.Name != actual.Name {
+		t.Errorf("Expected name %q, got %q", expected.Name, actual.Name)
+	}
+	if expected.Value != actual.Value {
+		t.Errorf("Expected value %q, got %q", expected.Value, actual.Value)
+	}
+	if expected.HeldByUs != actual.HeldByUs {
+		t.Errorf("Expected held by us = %v, got %v", expected.HeldByUs, actual.HeldByUs)
+	}
+	if len(expected.Pools) != len(actual.Pools) {
+		t.Errorf("Expected %d pools, got %d", len(expected.Pools), len(actual.Pools))
+		return
+	}
+	for i, e := range expected.Pools {
+		a := actual.Pools[i]
+		e.TTL, a.TTL = 0, 0
+		if e != a {
+			t.Errorf("Expected pool #%d status %+v, got %+v", i, e, a)
+		}
+	}
+}
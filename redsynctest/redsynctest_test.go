@@ -0,0 +1,54 @@
+package redsynctest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/go-redsync/redsync/v4/redis/memory"
+)
+
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingTB) Helper() {}
+
+func (t *recordingTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertSnapshotEqual(t *testing.T) {
+	rs := redsync.New([]redis.Pool{memory.NewPool(), memory.NewPool(), memory.NewPool()}...)
+	mutex := rs.NewMutex("test-assert-snapshot-equal")
+
+	before, err := mutex.Snapshot()
+	if err != nil {
+		t.Fatalf("mutex snapshot failed: %s", err)
+	}
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	locked, err := mutex.Snapshot()
+	if err != nil {
+		t.Fatalf("mutex snapshot failed: %s", err)
+	}
+	if !locked.HeldByUs || locked.TTL <= 0 {
+		t.Fatalf("Expected snapshot of a held lock, got %+v", locked)
+	}
+	again, err := mutex.Snapshot()
+	if err != nil {
+		t.Fatalf("mutex snapshot failed: %s", err)
+	}
+
+	AssertSnapshotEqual(t, locked, again)
+
+	rec := &recordingTB{TB: t}
+	AssertSnapshotEqual(rec, before, locked)
+	if len(rec.errors) == 0 {
+		t.Fatalf("Expected snapshots before and after locking to differ")
+	}
+}
@@ -0,0 +1,177 @@
+package redsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/hashicorp/go-multierror"
+)
+
+// A LockRegistry is a Redis hash listing the locks currently held by all the Redsync instances of a fleet that share
+// it. Mutexes created with the WithLockRegistry option add themselves to the registry when they lock or extend and
+// remove themselves when they unlock. It provides cluster-wide visibility of locks without scanning the keyspace.
+type LockRegistry struct {
+	key   string
+	owner string
+	pools []redis.Pool
+}
+
+// A LockInfo describes a lock listed in a LockRegistry.
+type LockInfo struct {
+	Name       string    `json:"name"`
+	Value      string    `json:"value"`
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	Until      time.Time `json:"until"`
+}
+
+// NewLockRegistry returns a lock registry stored in the Redis hash key on r's pools. Locks are registered with an
+// owner of the form "hostname:pid".
+func (r *Redsync) NewLockRegistry(key string) *LockRegistry {
+	return &LockRegistry{
+		key:   key,
+		owner: processOwner(),
+		pools: r.pools,
+	}
+}
+
+// processOwner identifies the current process as "hostname:pid".
+func processOwner() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
+
+var registryAddScript = redis.NewScript(1, `
+	redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+	return 1
+`)
+
+var registryRemoveScript = redis.NewScript(1, `
+	local info = redis.call("HGET", KEYS[1], ARGV[1])
+	if info and cjson.decode(info)["value"] == ARGV[2] then
+		return redis.call("HDEL", KEYS[1], ARGV[1])
+	end
+	return 0
+`)
+
+var registryListScript = redis.NewScript(1, `
+	return redis.call("HGETALL", KEYS[1])
+`)
+
+// List returns the locks currently registered, sorted by name. Entries whose validity has passed are omitted. If
+// some pools cannot be queried, the locks listed in the other pools are returned along with the error.
+func (reg *LockRegistry) List(ctx context.Context) ([]LockInfo, error) {
+	var (
+		mu    sync.Mutex
+		infos = map[string]LockInfo{}
+	)
+	err := reg.each(ctx, func(conn redis.Conn) error {
+		reply, err := conn.Eval(registryListScript, reg.key)
+		if err != nil {
+			return err
+		}
+		values := replySlice(reply)
+		mu.Lock()
+		defer mu.Unlock()
+		for i := 1; i < len(values); i += 2 {
+			var info LockInfo
+			if err := json.Unmarshal([]byte(replyString(values[i])), &info); err != nil {
+				continue
+			}
+			if prev, ok := infos[info.Name]; !ok || info.AcquiredAt.After(prev.AcquiredAt) {
+				infos[info.Name] = info
+			}
+		}
+		return nil
+	})
+
+	now := time.Now()
+	list := make([]LockInfo, 0, len(infos))
+	for _, info := range infos {
+		if now.Before(info.Until) {
+			list = append(list, info)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, err
+}
+
+func (reg *LockRegistry) add(ctx context.Context, info LockInfo) error {
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return reg.each(ctx, func(conn redis.Conn) error {
+		_, err := conn.Eval(registryAddScript, reg.key, info.Name, string(b))
+		return err
+	})
+}
+
+func (reg *LockRegistry) remove(ctx context.Context, name, value string) error {
+	return reg.each(ctx, func(conn redis.Conn) error {
+		_, err := conn.Eval(registryRemoveScript, reg.key, name, value)
+		return err
+	})
+}
+
+// each calls fn concurrently with a connection to each of the registry's pools.
+func (reg *LockRegistry) each(ctx context.Context, fn func(conn redis.Conn) error) error {
+	errs := make([]error, len(reg.pools))
+	var wg sync.WaitGroup
+	for node, pool := range reg.pools {
+		wg.Add(1)
+		go func(node int, pool redis.Pool) {
+			defer wg.Done()
+			conn, err := pool.Get(ctx)
+			if err != nil {
+				errs[node] = err
+				return
+			}
+			defer conn.Close()
+			errs[node] = fn(conn)
+		}(node, pool)
+	}
+	wg.Wait()
+
+	var err error
+	for node, e := range errs {
+		if e != nil {
+			err = multierror.Append(err, &RedisError{Node: node, Err: e})
+		}
+	}
+	return err
+}
+
+// register records the lock held by m in its registry, if any. Registry updates are best effort and never cause a
+// mutex operation to fail.
+func (m *Mutex) register(ctx context.Context) {
+	if m.registry == nil {
+		return
+	}
+	m.lockMu.RLock()
+	info := LockInfo{
+		Name:       m.name,
+		Value:      m.value,
+		Owner:      m.registry.owner,
+		AcquiredAt: m.acquiredAt,
+		Until:      m.until,
+	}
+	m.lockMu.RUnlock()
+	_ = m.registry.add(ctx, info)
+}
+
+// unregister removes the lock held by m from its registry, if any.
+func (m *Mutex) unregister(ctx context.Context) {
+	if m.registry == nil {
+		return
+	}
+	_ = m.registry.remove(ctx, m.name, m.Value())
+}
@@ -0,0 +1,53 @@
+package redsync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockRegistry(t *testing.T) {
+	ctx := context.Background()
+	for k, v := range makeCases(4) {
+		t.Run(k, func(t *testing.T) {
+			rs := New(v.pools...)
+			registry := rs.NewLockRegistry(k + "-test-registry")
+
+			mutex1 := rs.NewMutex(k+"-test-registry-lock-1", WithLockRegistry(registry))
+			mutex2 := rs.NewMutex(k+"-test-registry-lock-2", WithLockRegistry(registry), WithExpiry(time.Hour))
+			for _, mutex := range []*Mutex{mutex1, mutex2} {
+				err := mutex.Lock()
+				if err != nil {
+					t.Fatalf("mutex lock failed: %s", err)
+				}
+			}
+
+			infos, err := registry.List(ctx)
+			if err != nil {
+				t.Fatalf("registry list failed: %s", err)
+			}
+			if len(infos) != 2 {
+				t.Fatalf("Expected 2 registered locks, got %d", len(infos))
+			}
+			for i, mutex := range []*Mutex{mutex1, mutex2} {
+				if infos[i].Name != mutex.Name() || infos[i].Value != mutex.Value() || infos[i].Owner == "" {
+					t.Fatalf("Expected lock info for %q, got %+v", mutex.Name(), infos[i])
+				}
+			}
+
+			ok, err := mutex1.Unlock()
+			if err != nil || !ok {
+				t.Fatalf("mutex unlock failed: %v", err)
+			}
+
+			infos, err = registry.List(ctx)
+			if err != nil {
+				t.Fatalf("registry list failed: %s", err)
+			}
+			if len(infos) != 1 || infos[0].Name != mutex2.Name() {
+				t.Fatalf("Expected only %q to be registered, got %+v", mutex2.Name(), infos)
+			}
+			_, _ = mutex2.Unlock()
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package redsync
+
+import (
+	"errors"
+	"strconv"
+)
+
+var errUnexpectedReply = errors.New("redsync: unexpected reply from Redis")
+
+// The helpers below normalize script replies across drivers. Redigo returns bulk strings as []byte while go-redis and
+// rueidis return them as string.
+
+func replyString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return ""
+}
+
+func replyInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	case []byte:
+		n, _ := strconv.ParseInt(string(v), 10, 64)
+		return n
+	}
+	return 0
+}
+
+func replySlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
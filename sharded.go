@@ -0,0 +1,38 @@
+package redsync
+
+import (
+	"hash/fnv"
+)
+
+// A ShardedRedsync spreads locks over several Redsync instances, each with its own set of pools, to scale lock
+// throughput horizontally. Each lock name is consistently mapped to one of the shards.
+type ShardedRedsync struct {
+	shards []*Redsync
+}
+
+// NewSharded returns a ShardedRedsync distributing locks over the given shards, or ErrNoShards if there are none. The
+// order of the shards determines which shard a name maps to, so it must be the same for all the processes sharing the
+// locks.
+func NewSharded(shards ...*Redsync) (*ShardedRedsync, error) {
+	if len(shards) == 0 {
+		return nil, ErrNoShards
+	}
+	return &ShardedRedsync{shards: shards}, nil
+}
+
+// NumShards returns the number of shards of s.
+func (s *ShardedRedsync) NumShards() int {
+	return len(s.shards)
+}
+
+// Shard returns the shard that locks with the given name are held on.
+func (s *ShardedRedsync) Shard(name string) *Redsync {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// NewMutex returns a new distributed mutex with given name, on the shard the name maps to.
+func (s *ShardedRedsync) NewMutex(name string, options ...Option) *Mutex {
+	return s.Shard(name).NewMutex(name, options...)
+}
@@ -0,0 +1,20 @@
+//go:build go1.21
+
+package redsync
+
+import (
+	"log/slog"
+)
+
+// WithSlogLogger can be used to emit structured log records of the mutex's operations to logger. Retries are logged
+// at the debug level, acquisitions and releases at the info level, failures to reach a quorum at the warn level and
+// Redis errors at the error level.
+func WithSlogLogger(logger *slog.Logger) Option {
+	return OptionFunc(func(m *Mutex) {
+		if logger == nil {
+			m.logger = nil
+			return
+		}
+		m.logger = logger
+	})
+}
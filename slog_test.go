@@ -0,0 +1,50 @@
+//go:build go1.21
+
+package redsync
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis/memory"
+)
+
+func TestMutexSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	rs := New(memory.NewPool())
+
+	mutex1 := rs.NewMutex("test-slog-logger", WithSlogLogger(logger))
+	err := mutex1.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	mutex2 := rs.NewMutex("test-slog-logger", WithSlogLogger(logger), WithTries(2), WithRetryDelay(time.Millisecond))
+	err = mutex2.Lock()
+	if err == nil {
+		t.Fatalf("Expected error when locking a locked mutex")
+	}
+	_, err = mutex1.Unlock()
+	if err != nil {
+		t.Fatalf("mutex unlock failed: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := []string{
+		`level=INFO msg="redsync: lock acquired" name=test-slog-logger`,
+		`level=DEBUG msg="redsync: retrying lock" name=test-slog-logger attempt=1`,
+		`level=WARN msg="redsync: lock failed to reach quorum" name=test-slog-logger`,
+		`level=INFO msg="redsync: lock released" name=test-slog-logger`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d log records, got %q", len(expected), lines)
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, expected[i]) {
+			t.Fatalf("Expected log record %q to contain %q", line, expected[i])
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package redsync
+
+// A MutexState is the lifecycle state of a mutex, as tracked when the WithStateMachine option is used.
+type MutexState int
+
+const (
+	// StateCreated is the state of a mutex that has never been locked.
+	StateCreated MutexState = iota
+	// StateLocking is the state of a mutex while a lock is being acquired.
+	StateLocking
+	// StateLocked is the state of a mutex that holds the lock.
+	StateLocked
+	// StateUnlocking is the state of a mutex while the lock is being released.
+	StateUnlocking
+	// StateUnlocked is the state of a mutex that has released the lock. It may be locked again.
+	StateUnlocked
+)
+
+func (s MutexState) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StateLocking:
+		return "locking"
+	case StateLocked:
+		return "locked"
+	case StateUnlocking:
+		return "unlocking"
+	case StateUnlocked:
+		return "unlocked"
+	}
+	return "unknown"
+}
+
+// State returns the current state of m. It always returns StateCreated unless the WithStateMachine option is used.
+func (m *Mutex) State() MutexState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// beginTransition moves m to state to if it is currently in one of the states in from, and returns the state m was in.
+// It is a no-op unless the state machine is enabled.
+func (m *Mutex) beginTransition(to MutexState, from ...MutexState) (MutexState, error) {
+	if !m.stateMachine {
+		return StateCreated, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range from {
+		if m.state == s {
+			m.state = to
+			return s, nil
+		}
+	}
+	return m.state, &ErrInvalidTransition{From: m.state, To: to}
+}
+
+// endTransition moves m to state s. It is a no-op unless the state machine is enabled.
+func (m *Mutex) endTransition(s MutexState) {
+	if !m.stateMachine {
+		return
+	}
+	m.mu.Lock()
+	m.state = s
+	m.mu.Unlock()
+}
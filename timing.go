@@ -0,0 +1,110 @@
+package redsync
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// AcquireDetails describes the timing of an attempt to acquire a lock, measured from the start of the attempt. It can
+// be used to tune the timeout and drift factors.
+type AcquireDetails struct {
+	// Attempt is the number of the attempt, starting at 1, within the call to Lock.
+	Attempt int
+	// FirstResponse is the time until the first pool responded, successfully or not.
+	FirstResponse time.Duration
+	// QuorumResponse is the time until a quorum of pools had granted the lock. It is zero if no quorum was reached.
+	QuorumResponse time.Duration
+	// Confirmed is the time until the attempt completed and the validity of the lock was computed.
+	Confirmed time.Duration
+	// Drift is the clock drift subtracted from the validity of the lock.
+	Drift time.Duration
+	// Validity is the remaining validity of the lock when the attempt completed. It is negative if the lock could not
+	// be acquired in time.
+	Validity time.Duration
+	// Pools holds the timing of each pool, by node.
+	Pools []PoolTiming
+}
+
+// A PoolTiming describes the response of a pool to an attempt to acquire a lock.
+type PoolTiming struct {
+	Node int
+	// Responded is false if the attempt completed, for instance with WithFailFast, before the pool responded.
+	Responded bool
+	Latency   time.Duration
+	Acquired  bool
+	Err       error
+}
+
+// LastAcquireDetails returns the timing of the last attempt to acquire the lock with m, successful or not. The value
+// is zero until an attempt has completed.
+func (m *Mutex) LastAcquireDetails() AcquireDetails {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	details := m.lastAcquire
+	details.Pools = append([]PoolTiming(nil), details.Pools...)
+	return details
+}
+
+func (m *Mutex) setAcquireDetails(details AcquireDetails) {
+	m.mu.Lock()
+	m.lastAcquire = details
+	m.mu.Unlock()
+}
+
+// acquireTimings collects the responses of the pools to an attempt to acquire a lock.
+type acquireTimings struct {
+	mu    sync.Mutex
+	start time.Time
+	pools []PoolTiming
+}
+
+func newAcquireTimings(start time.Time, n int) *acquireTimings {
+	pools := make([]PoolTiming, n)
+	for i := range pools {
+		pools[i].Node = i
+	}
+	return &acquireTimings{start: start, pools: pools}
+}
+
+// record records the response of the pool node.
+func (t *acquireTimings) record(node int, acquired bool, err error) {
+	latency := time.Since(t.start)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pools[node] = PoolTiming{Node: node, Responded: true, Latency: latency, Acquired: acquired, Err: err}
+}
+
+// details returns the details of attempt, given the quorum, the drift and the time of validity of the lock.
+func (t *acquireTimings) details(attempt, quorum int, drift time.Duration, until time.Time) AcquireDetails {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	details := AcquireDetails{
+		Attempt:   attempt,
+		Confirmed: now.Sub(t.start),
+		Drift:     drift,
+		Validity:  until.Sub(now),
+		Pools:     append([]PoolTiming(nil), t.pools...),
+	}
+	var acquired []time.Duration
+	for _, p := range t.pools {
+		if !p.Responded {
+			continue
+		}
+		if details.FirstResponse == 0 || p.Latency < details.FirstResponse {
+			details.FirstResponse = p.Latency
+		}
+		if p.Acquired {
+			acquired = append(acquired, p.Latency)
+		}
+	}
+	if len(acquired) >= quorum {
+		sort.Slice(acquired, func(i, j int) bool {
+			return acquired[i] < acquired[j]
+		})
+		details.QuorumResponse = acquired[quorum-1]
+	}
+	return details
+}
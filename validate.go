@@ -0,0 +1,81 @@
+package redsync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// validateKey is the key used by Validate to exercise the lock scripts. It is never set.
+const validateKey = "redsync:validate"
+
+// Validate checks that r can be used for locking, for instance before serving traffic or in a readiness probe. For each
+// pool, it checks that the pool replies to commands, and that the scripts used to release and extend locks can be run:
+// it returns ErrScriptingUnsupported for servers that predate scripting, which was introduced in Redis 2.6. It then
+// checks that a quorum of pools passed all the checks. It returns a ValidationErrors listing all the failures, or nil.
+func (r *Redsync) Validate(ctx context.Context) error {
+	var (
+		mu      sync.Mutex
+		errs    ValidationErrors
+		healthy int
+		wg      sync.WaitGroup
+	)
+	for node, pool := range r.pools {
+		wg.Add(1)
+		go func(node int, pool redis.Pool) {
+			defer wg.Done()
+			poolErrs := validatePool(ctx, pool)
+			mu.Lock()
+			defer mu.Unlock()
+			if len(poolErrs) == 0 {
+				healthy++
+			}
+			for _, err := range poolErrs {
+				errs = append(errs, &RedisError{Node: node, Err: err})
+			}
+		}(node, pool)
+	}
+	wg.Wait()
+
+	if quorum := len(r.pools)/2 + 1; healthy < quorum {
+		errs = append(errs, fmt.Errorf("only %d of %d pools are healthy, need %d", healthy, len(r.pools), quorum))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validatePool returns the failures of the checks of Validate on pool.
+func validatePool(ctx context.Context, pool redis.Pool) []error {
+	conn, err := pool.Get(ctx)
+	if err != nil {
+		return []error{fmt.Errorf("connect: %w", err)}
+	}
+	defer conn.Close()
+	// Conn has no PING: reading a key that is never set checks that the server replies.
+	if _, err := conn.Get(validateKey); err != nil {
+		return []error{fmt.Errorf("connect: %w", err)}
+	}
+
+	// Neither script modifies a key that is not set to the given value.
+	if _, err := conn.Eval(deleteScript, validateKey, ""); err != nil {
+		return []error{scriptError(err)}
+	}
+	if _, err := conn.Eval(touchScript, validateKey, "", 1); err != nil {
+		return []error{scriptError(err)}
+	}
+	return nil
+}
+
+// scriptError returns the failure of Validate for an error evaluating a script. Servers without scripting reject
+// EVAL and EVALSHA as unknown commands.
+func scriptError(err error) error {
+	if strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+		return fmt.Errorf("script: %w: %v", ErrScriptingUnsupported, err)
+	}
+	return fmt.Errorf("script: %w", err)
+}
@@ -0,0 +1,125 @@
+// Package vault creates Redsync instances from Redis credentials stored in HashiCorp Vault.
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+	goredis "github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	"github.com/hashicorp/vault/api"
+	"github.com/redis/go-redis/v9"
+)
+
+// A PoolOption configures the go-redis client created by NewFromVault.
+type PoolOption = goredis.PoolOption
+
+// DefaultRefreshInterval is how often credentials are fetched again from secrets without a lease, such as those of the
+// KV secrets engine.
+var DefaultRefreshInterval = 5 * time.Minute
+
+// ErrInvalidSecret is the error resulting if the Vault secret does not hold a Redis address.
+var ErrInvalidSecret = errors.New("redsync/vault: secret has no Redis address")
+
+// credentials are the Redis credentials fetched from Vault. They are safe for concurrent use.
+type credentials struct {
+	mu       sync.Mutex
+	username string
+	password string
+}
+
+func (c *credentials) get() (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.username, c.password
+}
+
+func (c *credentials) set(username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.username, c.password = username, password
+}
+
+// NewFromVault returns a Redsync instance with a single go-redis pool, built from the Vault secret at secretPath. The
+// secret, from the KV secrets engine version 1 or 2, holds the Redis server's "address" and optionally the "username"
+// and "password" to authenticate with.
+//
+// The credentials are fetched again from Vault before the lease of the secret expires, or every
+// DefaultRefreshInterval for secrets without a lease, until ctx is done. New connections use the latest credentials.
+func NewFromVault(ctx context.Context, vaultClient *api.Client, secretPath string, poolOptions ...PoolOption) (*redsync.Redsync, error) {
+	secret, data, err := read(ctx, vaultClient, secretPath)
+	if err != nil {
+		return nil, err
+	}
+	address, _ := data["address"].(string)
+	if address == "" {
+		return nil, ErrInvalidSecret
+	}
+
+	creds := &credentials{}
+	creds.set(stringValue(data, "username"), stringValue(data, "password"))
+
+	options := &redis.Options{
+		Addr:                address,
+		CredentialsProvider: creds.get,
+	}
+	for _, opt := range poolOptions {
+		opt(options)
+	}
+	go rotate(ctx, vaultClient, secretPath, secret, creds)
+	return redsync.New(goredis.NewPool(redis.NewClient(options))), nil
+}
+
+// rotate fetches the credentials from Vault again before the lease of secret expires, until ctx is done.
+func rotate(ctx context.Context, vaultClient *api.Client, secretPath string, secret *api.Secret, creds *credentials) {
+	for {
+		timer := time.NewTimer(refreshInterval(secret))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		next, data, err := read(ctx, vaultClient, secretPath)
+		if err != nil {
+			// Keep the current credentials and retry after the same interval.
+			continue
+		}
+		secret = next
+		creds.set(stringValue(data, "username"), stringValue(data, "password"))
+	}
+}
+
+// refreshInterval returns the time to wait before fetching secret again: two thirds of its lease, or
+// DefaultRefreshInterval if it has none.
+func refreshInterval(secret *api.Secret) time.Duration {
+	if secret.LeaseDuration <= 0 {
+		return DefaultRefreshInterval
+	}
+	return time.Duration(secret.LeaseDuration) * time.Second * 2 / 3
+}
+
+// read returns the secret at secretPath and its data, unwrapping the data of KV version 2 secrets.
+func read(ctx context.Context, vaultClient *api.Client, secretPath string) (*api.Secret, map[string]interface{}, error) {
+	secret, err := vaultClient.Logical().ReadWithContext(ctx, secretPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil, fmt.Errorf("redsync/vault: no secret at %q", secretPath)
+	}
+	data := secret.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		data = inner
+	}
+	return secret, data, nil
+}
+
+func stringValue(data map[string]interface{}, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
@@ -0,0 +1,79 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stvp/tempredis"
+)
+
+func TestNewFromVault(t *testing.T) {
+	server, err := tempredis.Start(tempredis.Config{"port": "51400"})
+	if err != nil {
+		t.Fatalf("redis server start failed: %s", err)
+	}
+	defer server.Term()
+
+	var reads int32
+	vaultServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/redis" {
+			http.NotFound(w, r)
+			return
+		}
+		n := atomic.AddInt32(&reads, 1)
+		password := ""
+		if n > 1 {
+			password = "rotated"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_duration": 1,
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{
+					"address":  "127.0.0.1:51400",
+					"password": password,
+				},
+			},
+		})
+	}))
+	defer vaultServer.Close()
+
+	client, err := api.NewClient(&api.Config{Address: vaultServer.URL})
+	if err != nil {
+		t.Fatalf("vault client failed: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rs, err := NewFromVault(ctx, client, "secret/data/redis")
+	if err != nil {
+		t.Fatalf("new from vault failed: %s", err)
+	}
+	mutex := rs.NewMutex("test-vault")
+	err = mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	_, err = mutex.Unlock()
+	if err != nil {
+		t.Fatalf("mutex unlock failed: %s", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&reads) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected credentials to be fetched again before the lease expires")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, err = NewFromVault(ctx, client, "secret/data/missing")
+	if err == nil {
+		t.Fatalf("Expected error for a missing secret")
+	}
+}
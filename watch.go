@@ -0,0 +1,187 @@
+package redsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis"
+)
+
+// LockAndWatch locks m and starts watching for the loss of the lock, for instance if it expires without being extended
+// or is deleted from Redis. The lock is polled every third of the expiry; onLost is called once, from the watcher
+// goroutine, if the lock is no longer held on a quorum of pools. The watcher stops when ctx is done.
+//
+// The returned CancelFunc stops the watcher and unlocks m. It must be called once the lock is no longer needed.
+func (m *Mutex) LockAndWatch(ctx context.Context, onLost func()) (context.CancelFunc, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := m.LockContext(ctx); err != nil {
+		return nil, err
+	}
+
+	watchCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if m.watch(watchCtx, m.Value()) && onLost != nil {
+			onLost()
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			stop()
+			<-done
+			_, _ = m.UnlockContext(context.Background())
+		})
+	}, nil
+}
+
+// AcquiredContext returns a child of ctx that is cancelled when ctx is, when m is unlocked, or when the lock held by m
+// is detected as lost, either by polling it every third of the expiry or by an unlock finding it already expired. In
+// the latter case, the cause of the context, as returned by context.Cause, is ErrLockLost. m must hold the lock; otherwise the returned context is already
+// cancelled. The returned CancelFunc releases the resources of the context but does not unlock m.
+func (m *Mutex) AcquiredContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	acquiredCtx, cancelCause := context.WithCancelCause(ctx)
+	cancel := func() { cancelCause(context.Canceled) }
+
+	m.mu.Lock()
+	value, until, _ := m.lockState()
+	if value == "" || !time.Now().Before(until) {
+		m.mu.Unlock()
+		cancelCause(ErrLockLost)
+		return acquiredCtx, cancel
+	}
+	if m.acquiredCancels == nil {
+		m.acquiredCancels = map[*context.CancelCauseFunc]struct{}{}
+	}
+	key := &cancelCause
+	m.acquiredCancels[key] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		if m.watch(acquiredCtx, value) {
+			cancelCause(ErrLockLost)
+		}
+		m.mu.Lock()
+		delete(m.acquiredCancels, key)
+		m.mu.Unlock()
+	}()
+	return acquiredCtx, cancel
+}
+
+// ScopedLock locks m, calls scope with a context that is cancelled when the lock expires, is lost or is unlocked, and
+// then unlocks m. The context is cancelled at the end of the validity of the lock, unless the lock is extended in the
+// meantime, and when the loss of the lock is detected as with AcquiredContext.
+//
+// If scope returns context.Canceled because the lock was lost, ScopedLock returns ErrLockLostDuringScope. Otherwise it
+// returns the error of scope or, if scope succeeded, the error of unlocking m.
+func (m *Mutex) ScopedLock(ctx context.Context, scope func(lockCtx context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := m.LockContext(ctx); err != nil {
+		return err
+	}
+
+	acquiredCtx, cancel := m.AcquiredContext(ctx)
+	defer cancel()
+	lockCtx, cancelCause := context.WithCancelCause(acquiredCtx)
+	defer cancelCause(context.Canceled)
+	go m.cancelAtExpiry(lockCtx, cancelCause, m.Until())
+
+	err := scope(lockCtx)
+	lost := errors.Is(context.Cause(lockCtx), ErrLockLost)
+	_, unlockErr := m.UnlockContext(context.WithoutCancel(ctx))
+	if lost && errors.Is(err, context.Canceled) {
+		return ErrLockLostDuringScope
+	}
+	if err != nil || lost {
+		return err
+	}
+	return unlockErr
+}
+
+// cancelAtExpiry cancels ctx with ErrLockLost at until, or at the end of the validity of the lock after it is
+// extended, unless ctx is done first.
+func (m *Mutex) cancelAtExpiry(ctx context.Context, cancel context.CancelCauseFunc, until time.Time) {
+	for {
+		m.mu.Lock()
+		if m.extended == nil {
+			m.extended = make(chan struct{})
+		}
+		extended := m.extended
+		m.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(until))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			cancel(ErrLockLost)
+			return
+		case <-extended:
+			timer.Stop()
+			until = m.Until()
+		}
+	}
+}
+
+// cancelAcquiredContexts cancels the contexts returned by AcquiredContext with cause, as the lock is released or
+// found to be lost.
+func (m *Mutex) cancelAcquiredContexts(cause error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for cancel := range m.acquiredCancels {
+		(*cancel)(cause)
+	}
+	m.acquiredCancels = nil
+}
+
+// watch polls the lock with the given value every third of m's expiry until ctx is done or the lock is no longer held
+// on a quorum of pools. It returns true if the lock was lost.
+func (m *Mutex) watch(ctx context.Context, value string) bool {
+	interval := m.getExpiry() / 3
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	quorum := m.heldQuorum()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+
+		n, _ := m.actOnPoolsAsync(func(pool redis.Pool) (bool, error) {
+			conn, err := pool.Get(ctx)
+			if err != nil {
+				return false, err
+			}
+			defer conn.Close()
+			reply, err := conn.Get(m.name)
+			if err != nil {
+				return false, err
+			}
+			return reply == value, nil
+		})
+		if ctx.Err() != nil {
+			return false
+		}
+		if n < quorum {
+			m.history.record(EventExpire, 0, ErrLockLost)
+			return true
+		}
+	}
+}
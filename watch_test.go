@@ -0,0 +1,206 @@
+package redsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4/redis/memory"
+)
+
+func TestMutexLockAndWatch(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-lock-and-watch", WithExpiry(150*time.Millisecond))
+
+	lost := make(chan struct{})
+	cancel, err := mutex.LockAndWatch(context.Background(), func() { close(lost) })
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	defer cancel()
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected expired lock to be reported as lost")
+	}
+}
+
+func TestMutexLockAndWatchCancel(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-lock-and-watch-cancel", WithExpiry(time.Second))
+
+	cancel, err := mutex.LockAndWatch(context.Background(), func() {
+		t.Errorf("Expected lock not to be reported as lost")
+	})
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+	cancel()
+
+	err = rs.NewMutex("test-lock-and-watch-cancel").TryLock()
+	if err != nil {
+		t.Fatalf("Expected lock to be released, got %s", err)
+	}
+}
+
+func TestMutexAcquiredContext(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-acquired-context", WithExpiry(150*time.Millisecond))
+
+	ctx, cancel := mutex.AcquiredContext(context.Background())
+	defer cancel()
+	if !errors.Is(context.Cause(ctx), ErrLockLost) {
+		t.Fatalf("Expected context of an unlocked mutex to be cancelled, got %v", context.Cause(ctx))
+	}
+
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	ctx, cancel = mutex.AcquiredContext(context.Background())
+	defer cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Expected context to be cancelled when the lock expires")
+	}
+	if !errors.Is(context.Cause(ctx), ErrLockLost) {
+		t.Fatalf("Expected cause %q, got %v", ErrLockLost, context.Cause(ctx))
+	}
+}
+
+func TestMutexAcquiredContextUnlock(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-acquired-context-unlock", WithExpiry(time.Second))
+
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	ctx, cancel := mutex.AcquiredContext(context.Background())
+	defer cancel()
+	if ctx.Err() != nil {
+		t.Fatalf("Expected context not to be cancelled while the lock is held")
+	}
+
+	ok, err := mutex.Unlock()
+	if err != nil || !ok {
+		t.Fatalf("mutex unlock failed: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected context to be cancelled on unlock")
+	}
+	if errors.Is(context.Cause(ctx), ErrLockLost) {
+		t.Fatalf("Expected cause not to be %q", ErrLockLost)
+	}
+}
+
+func TestMutexAcquiredContextUnlockExpired(t *testing.T) {
+	ctx := context.Background()
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-acquired-context-unlock-expired", WithExpiry(time.Minute))
+
+	err := mutex.Lock()
+	if err != nil {
+		t.Fatalf("mutex lock failed: %s", err)
+	}
+	acquiredCtx, cancel := mutex.AcquiredContext(ctx)
+	defer cancel()
+	if _, err := rs.NewMutex("test-acquired-context-unlock-expired").ForceUnlock(ctx); err != nil {
+		t.Fatalf("mutex force unlock failed: %s", err)
+	}
+
+	_, err = mutex.Unlock()
+	if !errors.Is(err, ErrLockAlreadyExpired) {
+		t.Fatalf("Expected %q, got %v", ErrLockAlreadyExpired, err)
+	}
+	if !errors.Is(context.Cause(acquiredCtx), ErrLockLost) {
+		t.Fatalf("Expected cause %q, got %v", ErrLockLost, context.Cause(acquiredCtx))
+	}
+}
+
+func TestMutexScopedLock(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-scoped-lock", WithExpiry(time.Second))
+
+	err := mutex.ScopedLock(context.Background(), func(ctx context.Context) error {
+		if err := rs.NewMutex("test-scoped-lock", WithTries(1)).TryLock(); err == nil {
+			t.Errorf("Expected the lock to be held during the scope")
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("mutex scoped lock failed: %s", err)
+	}
+	err = rs.NewMutex("test-scoped-lock").TryLock()
+	if err != nil {
+		t.Fatalf("Expected lock to be released, got %s", err)
+	}
+
+	scopeErr := errors.New("scope failed")
+	err = rs.NewMutex("test-scoped-lock-error").ScopedLock(context.Background(), func(ctx context.Context) error {
+		return scopeErr
+	})
+	if err != scopeErr {
+		t.Fatalf("Expected err == %q, got %q", scopeErr, err)
+	}
+}
+
+func TestMutexScopedLockLost(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-scoped-lock-lost", WithExpiry(150*time.Millisecond))
+
+	err := mutex.ScopedLock(context.Background(), func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			t.Errorf("Expected the scope to be cancelled when the lock expires")
+			return nil
+		}
+	})
+	if err != ErrLockLostDuringScope {
+		t.Fatalf("Expected err == %q, got %q", ErrLockLostDuringScope, err)
+	}
+}
+
+func TestMutexScopedLockConcurrentExtend(t *testing.T) {
+	rs := New(memory.NewPool())
+	mutex := rs.NewMutex("test-scoped-lock-extend", WithExpiry(300*time.Millisecond))
+
+	err := mutex.ScopedLock(context.Background(), func(ctx context.Context) error {
+		acquiredCtx, cancel := mutex.AcquiredContext(ctx)
+		defer cancel()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 5; j++ {
+					if _, err := mutex.Extend(); err != nil {
+						t.Errorf("mutex extend failed: %s", err)
+						return
+					}
+					_ = mutex.Until()
+					time.Sleep(50 * time.Millisecond)
+				}
+			}()
+		}
+		wg.Wait()
+		if acquiredCtx.Err() != nil {
+			t.Errorf("Expected the acquired context to outlive the extended lock, got %s", context.Cause(acquiredCtx))
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("mutex scoped lock failed: %s", err)
+	}
+}